@@ -0,0 +1,97 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/ui"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errNotRunning is shown when attaching to a command that cannot take input
+var errNotRunning = errors.New("select the running command to attach to it")
+
+// errNoInput is shown when attaching to a command whose input is closed
+var errNoInput = errors.New("this step reads no input: give it \"input\": true or pass --input")
+
+// attachTarget returns the command attaching would send input to: the one in
+// the output viewer, or the selected one in the list
+func (m Model) attachTarget() *executor.Command {
+	if m.mode != modeList {
+		return m.viewer.Command()
+	}
+	if row, ok := m.selectedRow(); ok && !row.isHeader() {
+		return m.commands[row.command]
+	}
+	return nil
+}
+
+// attach sends keyboard input to the running command. Its output is shown
+// while attached so prompts can be seen.
+func (m *Model) attach() tea.Cmd {
+	cmd := m.attachTarget()
+	if cmd == nil || cmd.Status != executor.StatusRunning {
+		m.notice = notice(errNotRunning)
+		return nil
+	}
+	if !cmd.AcceptsInput() {
+		m.notice = notice(errNoInput)
+		return nil
+	}
+
+	if m.mode == modeList {
+		m.openOutput(cmd)
+	}
+	m.attached = cmd
+	m.stdin.Prompt = "⌨ stdin › "
+	m.stdin.SetValue("")
+	m.resizeViewer()
+	return m.stdin.Focus()
+}
+
+// detach returns the keyboard to the interface
+func (m *Model) detach() {
+	m.attached = nil
+	m.stdin.Blur()
+	m.stdin.SetValue("")
+	m.resizeViewer()
+}
+
+// updateAttached handles key presses while attached: enter sends the line,
+// ctrl+d closes the command's input and the Detach binding gives the keyboard back
+func (m Model) updateAttached(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	cmd := m.attached
+	m.notice = ""
+
+	switch {
+	case key.Matches(msg, m.keys.Detach):
+		(&m).detach()
+		return m, nil
+	case msg.Type == tea.KeyEnter:
+		if err := cmd.SendInput(m.stdin.Value(), m.logger); err != nil {
+			(&m).detach()
+			m.notice = notice(err)
+			return m, nil
+		}
+		m.stdin.SetValue("")
+		m.viewer.Refresh()
+		return m, nil
+	case msg.Type == tea.KeyCtrlD:
+		// Like ctrl+d on a terminal: the command reads end-of-file
+		if err := cmd.CloseInput(); err != nil {
+			m.notice = notice(err)
+		}
+		(&m).detach()
+		return m, nil
+	}
+
+	var inputCmd tea.Cmd
+	m.stdin, inputCmd = m.stdin.Update(msg)
+	return m, inputCmd
+}
+
+// attachedLine renders the input line and focus indicator while attached
+func (m Model) attachedLine() string {
+	return ui.PromptStyle.Render("Input attached: keys go to the command") + "\n" + m.stdin.View()
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/cache"
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cachedMsg reports that a step's result was found in the cache instead of
+// running it
+type cachedMsg struct {
+	Index int
+	Key   string
+	Entry *cache.Entry
+	Err   error // Restoring the outputs failed
+}
+
+// stepLogger returns what a running step reports its start, output and end
+// to: the debug log, and the recording if there is one
+func (m *Model) stepLogger() executor.Logger {
+	if m.recorder == nil {
+		return m.logger
+	}
+	return executor.Loggers{m.logger, m.recorder}
+}
+
+// startCommand returns the tea.Cmd that runs command i. Steps with inputs
+// look for a cached result first and store theirs when they succeed.
+func (m *Model) startCommand(i int, cmd *executor.Command) tea.Cmd {
+	run := executor.ExecuteCommand(i, cmd, m.workingDir, m.shell, m.stepLogger())
+	if m.cache == nil || len(cmd.Inputs) == 0 {
+		return run
+	}
+
+	c, workingDir, shell := m.cache, m.workingDir, m.shell
+	cmd.Status = executor.StatusRunning
+	cmd.StartTime = time.Now()
+	return func() tea.Msg {
+		key, err := cache.Key(cmd, workingDir, shell)
+		if err != nil {
+			// Without a key the step simply runs
+			return run()
+		}
+		if entry, ok := c.Lookup(key); ok {
+			return cachedMsg{Index: i, Key: key, Entry: entry, Err: entry.Restore(workingDir)}
+		}
+
+		msg := run()
+		if done, ok := msg.(executor.CommandCompletedMsg); ok && done.Error == nil {
+			// A failure to store only means the step runs again next time
+			c.Store(key, cmd, workingDir, done.NewDir)
+		}
+		return msg
+	}
+}
+
+// applyCached records a cached result for its step. Outputs that could not be
+// restored fail the step like a failed command.
+func (m *Model) applyCached(msg cachedMsg) bool {
+	cmd := m.commands[msg.Index]
+	cmd.WorkingDir = m.workingDir
+	cmd.EndTime = time.Now()
+	cmd.Output = append(cmd.Output[:0], msg.Entry.Output...)
+	m.executing = -1
+
+	if msg.Err != nil {
+		cmd.Status = executor.StatusFailed
+		cmd.Error = msg.Err
+		cmd.ExitCode = 1
+		m.fail(cmd)
+		if m.logger != nil {
+			m.logger.LogCommandEnd(cmd)
+		}
+		m.SkipRemaining()
+		m.settleGroup(msg.Index)
+		return false
+	}
+
+	cmd.Status = executor.StatusCached
+	cmd.ExitCode = 0
+	// Later steps run where the step would have left them, as after a run
+	if msg.Entry.Dir != "" {
+		m.workingDir = msg.Entry.Dir
+	}
+	if m.logger != nil {
+		m.logger.LogCommandCached(cmd, msg.Key, len(msg.Entry.Files))
+	}
+	m.settleGroup(msg.Index)
+	return true
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// skipStopped is the reason recorded for steps left out of a stopped run
+const skipStopped = "the run was stopped"
+
+// fail records cmd as failed. The first failure is the one that stopped the
+// run, so a cleanup step failing afterwards does not replace it.
+func (m *Model) fail(cmd *executor.Command) {
+	if m.failedCommand == nil {
+		m.failedCommand = cmd
+	}
+}
+
+// showFailure opens the details of the failure that stopped the run. A
+// stopped run stays on the list so its cleanup steps can be followed.
+func (m *Model) showFailure() {
+	if m.stopping || m.failedCommand == nil {
+		return
+	}
+	m.openOutput(m.failedCommand)
+}
+
+// quit exits the program. If cleanup steps have yet to finish, the run is
+// stopped instead and the program exits once they are done; quitting again
+// exits right away.
+func (m *Model) quit() tea.Cmd {
+	running := m.runningCommand()
+	cleaning := running != nil && running.Always
+	if m.reviewing || m.replay != "" || m.stopping || !(cleaning || m.cleanupPending()) {
+		m.cancelActive()
+		return tea.Quit
+	}
+
+	m.stopRun()
+	m.notice = fmt.Sprintf("Running cleanup steps before quitting; press %s again to quit now", m.keys.Quit.Help().Key)
+	switch {
+	case cleaning && running.Status == executor.StatusPaused:
+		// A paused cleanup step would never finish
+		m.toggleSuspend()
+		return nil
+	case cleaning:
+		return nil
+	case m.executing >= 0:
+		// Cleanup starts once the cancelled command has stopped
+		m.cancelActive()
+		return nil
+	}
+	if m.AllCommandsDone() {
+		return m.runDone()
+	}
+	return m.executeNext()
+}
+
+// stopRun ends the run early: a waiting approval gate is dismissed and every
+// remaining step except the cleanup steps is skipped
+func (m *Model) stopRun() {
+	m.stopping = true
+	if m.attached != nil {
+		m.detach()
+	}
+
+	if cmd := m.gate; cmd != nil {
+		m.gate = nil
+		cmd.Status = executor.StatusSkipped
+		cmd.SkipReason = skipStopped
+		cmd.EndTime = time.Now()
+		if m.logger != nil {
+			m.logger.LogCommandSkipped(cmd)
+		}
+		m.settleGroup(m.indexOf(cmd))
+	}
+
+	m.skipRemaining(skipStopped, false)
+	m.mode = modeList
+}
+
+// cancelActive cancels the running command, if any
+func (m *Model) cancelActive() {
+	for _, cmd := range m.commands {
+		if cmd.Active() {
+			cmd.Cancel()
+		}
+	}
+}
+
+// cleanupPending reports whether any cleanup step has yet to run
+func (m Model) cleanupPending() bool {
+	for _, cmd := range m.commands {
+		if cmd.Always && cmd.Status == executor.StatusPending {
+			return true
+		}
+	}
+	return false
+}
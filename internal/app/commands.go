@@ -5,21 +5,69 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// executeNext starts executing the next pending command
+// executeNext starts executing the next pending command, or stops at an
+// approval gate until it is answered
 func (m *Model) executeNext() tea.Cmd {
-	// Find the next pending command
-	for i, cmd := range m.commands {
-		if cmd.Status == executor.StatusPending {
-			// Update executing index
-			m.executing = i
-			// Return batch: start execution + start ticker for UI refresh
-			return tea.Batch(
-				executor.ExecuteCommand(i, cmd, m.workingDir, m.logger),
-				executor.Ticker(),
-			)
+	// Find the next pending command whose condition holds
+	for i := m.nextRunnable(); i >= 0; i = m.nextRunnable() {
+		cmd := m.commands[i]
+		if cmd.Pause != "" {
+			m.waitForApproval(i)
+			if !m.approve {
+				return nil
+			}
+			m.resolveGate(true, approvedByFlag)
+			continue
 		}
+
+		// Update executing index
+		m.executing = i
+		// Keep the selection on the running command until the user moves it
+		if !m.manualSelect {
+			m.selectCommand(i)
+			m.syncPreview()
+		}
+		// Return batch: start execution + start ticker for UI refresh
+		return tea.Batch(
+			m.withStepHooks(cmd, m.startCommand(i, cmd)),
+			executor.Ticker(),
+		)
 	}
 
-	// No more commands to execute
+	// No more commands to execute, for instance after an approved final gate
+	if m.AllCommandsDone() {
+		return m.runDone()
+	}
 	return nil
 }
+
+// finishCommand records the result of a command. A failure stops the run and
+// skips the remaining commands, except for cleanup and conditional steps. It
+// reports whether the command succeeded.
+func (m *Model) finishCommand(msg executor.CommandCompletedMsg) bool {
+	cmd := m.commands[msg.Index]
+
+	// Update working directory if changed
+	if msg.NewDir != "" {
+		m.workingDir = msg.NewDir
+	}
+
+	cmd.ExitCode = msg.ExitCode
+	m.executing = -1
+
+	if msg.Error != nil {
+		// Command failed - stop execution
+		cmd.Status = executor.StatusFailed
+		cmd.Error = msg.Error
+		m.fail(cmd)
+
+		// Skip all remaining commands
+		m.SkipRemaining()
+		m.settleGroup(msg.Index)
+		return false
+	}
+
+	cmd.Status = executor.StatusCompleted
+	m.settleGroup(msg.Index)
+	return true
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/expr"
+)
+
+// nextRunnable returns the index of the next pending command whose condition
+// holds, or -1. Commands whose condition does not hold are skipped on the
+// way, and conditions that cannot be evaluated fail their command.
+func (m *Model) nextRunnable() int {
+	for i, cmd := range m.commands {
+		if cmd.Status != executor.StatusPending {
+			continue
+		}
+		if cmd.If == "" {
+			return i
+		}
+
+		ok, err := m.checkCondition(cmd)
+		switch {
+		case err != nil:
+			m.failCondition(i, err)
+		case ok:
+			return i
+		default:
+			cmd.Status = executor.StatusSkipped
+			cmd.SkipReason = fmt.Sprintf("condition not met: %s", cmd.If)
+			if m.logger != nil {
+				m.logger.LogCommandSkipped(cmd)
+			}
+			m.settleGroup(i)
+		}
+	}
+	return -1
+}
+
+// checkCondition evaluates a command's condition against the steps so far
+func (m Model) checkCondition(cmd *executor.Command) (bool, error) {
+	e, err := expr.Parse(cmd.If)
+	if err != nil {
+		return false, err
+	}
+	ctx := m.conditionContext()
+	ctx.Cleanup = cmd.Always
+	return e.Eval(ctx)
+}
+
+// reactsToFailure reports whether a command's condition can still hold after
+// a failure, because it calls success(), failure() or always(), or checks a
+// step's status or exit code. Conditions that cannot be parsed count too, so
+// checkCondition reports them.
+func reactsToFailure(cmd *executor.Command) bool {
+	if cmd.If == "" {
+		return false
+	}
+	e, err := expr.Parse(cmd.If)
+	return err != nil || e.ChecksStatus()
+}
+
+// conditionContext exposes the results of the steps with ids to conditions
+func (m Model) conditionContext() expr.Context {
+	ctx := expr.Context{
+		Steps:  make(map[string]expr.Step),
+		Env:    os.Getenv,
+		Failed: m.failedCommand != nil,
+	}
+	for _, cmd := range m.commands {
+		if cmd.StepID == "" {
+			continue
+		}
+		ctx.Steps[cmd.StepID] = expr.Step{
+			Status:   strings.ToLower(cmd.Status.String()),
+			ExitCode: cmd.ExitCode,
+			Output:   strings.Join(cmd.Output, "\n"),
+		}
+	}
+	return ctx
+}
+
+// failCondition fails command i because its condition could not be evaluated
+func (m *Model) failCondition(i int, err error) {
+	cmd := m.commands[i]
+	cmd.Status = executor.StatusFailed
+	cmd.Error = fmt.Errorf("condition %q: %w", cmd.If, err)
+	cmd.ExitCode = 1
+	cmd.StartTime = time.Now()
+	cmd.EndTime = cmd.StartTime
+	m.fail(cmd)
+
+	if m.logger != nil {
+		m.logger.LogCommandEnd(cmd)
+	}
+	m.SkipRemaining()
+	m.settleGroup(i)
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"io"
+	"testing"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/log"
+)
+
+func TestConditionsAfterFailure(t *testing.T) {
+	step := func(id int, run, cond string) *executor.Command {
+		cmd := executor.NewCommand(id, run)
+		cmd.If = cond
+		return cmd
+	}
+	commands := []*executor.Command{
+		step(0, "exit 3", ""),
+		step(1, "echo report", `steps.test.status == "failed"`),
+		step(2, "echo exit code", `steps.test.exit_code == 3`),
+		step(3, "echo on failure", `failure()`),
+		step(4, "echo deploy", `env.LAZYCOMMANDS_TEST_UNSET == ""`),
+		step(5, "echo next", ""),
+		step(6, "echo always", `always()`),
+	}
+	commands[0].StepID = "test"
+
+	m := NewModel(commands, Options{Shell: "/bin/sh", Log: log.Settings{Disabled: true}})
+	m = RunHeadless(m, io.Discard, nil)
+
+	want := []executor.CommandStatus{
+		executor.StatusFailed,
+		executor.StatusCompleted,
+		executor.StatusCompleted,
+		executor.StatusCompleted,
+		executor.StatusSkipped, // success() is implied
+		executor.StatusSkipped,
+		executor.StatusCompleted,
+	}
+	for i, cmd := range m.Commands() {
+		if cmd.Status != want[i] {
+			t.Errorf("step %d (%s) is %s, want %s", i, cmd.Raw, cmd.Status, want[i])
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/ui"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// approvedByFlag is recorded in the log for gates approved by --approve
+const approvedByFlag = "--approve"
+
+// waitForApproval stops the run at the approval gate i until it is answered
+func (m *Model) waitForApproval(i int) {
+	cmd := m.commands[i]
+	cmd.Status = executor.StatusWaiting
+	cmd.StartTime = time.Now()
+	m.gate = cmd
+
+	if m.logger != nil {
+		m.logger.LogPause(cmd)
+	}
+
+	if m.approve {
+		return
+	}
+
+	if !m.manualSelect {
+		m.selectCommand(i)
+	}
+
+	// The question is asked from the command list
+	m.mode = modeList
+	m.syncPreview()
+}
+
+// gatePrompt renders the question of the waiting approval gate
+func (m Model) gatePrompt() string {
+	return ui.PromptStyle.Render(fmt.Sprintf("%s %s [%s/%s]", ui.StatusIcon(executor.StatusWaiting),
+		m.gate.Pause, m.keys.Continue.Help().Key, m.keys.Stop.Help().Key))
+}
+
+// gateHint renders the waiting approval gate on the output screens, where
+// its keys belong to the viewer and the question is answered from the list
+func (m Model) gateHint() string {
+	return ui.PromptStyle.Render(fmt.Sprintf("%s %s (press %s to answer)", ui.StatusIcon(executor.StatusWaiting),
+		m.gate.Pause, m.keys.Back.Help().Key))
+}
+
+// handleGateKey answers the waiting approval gate with the Continue or Stop
+// binding. It reports false for other keys.
+func (m *Model) handleGateKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, m.keys.Continue):
+		m.resolveGate(true, "prompt")
+	case key.Matches(msg, m.keys.Stop):
+		m.resolveGate(false, "prompt")
+	default:
+		return nil, false
+	}
+
+	if m.AllCommandsDone() {
+		return m.runDone(), true
+	}
+	return m.executeNext(), true
+}
+
+// resolveGate records the answer to the waiting gate. Declining skips every
+// remaining step.
+func (m *Model) resolveGate(approved bool, by string) {
+	cmd := m.gate
+	if cmd == nil {
+		return
+	}
+	m.gate = nil
+	cmd.EndTime = time.Now()
+
+	if approved {
+		cmd.Status = executor.StatusCompleted
+	} else {
+		cmd.Status = executor.StatusSkipped
+		cmd.SkipReason = "declined"
+		m.declined = cmd
+	}
+
+	if m.logger != nil {
+		m.logger.LogApproval(cmd, approved, by)
+	}
+
+	if !approved {
+		m.skipRemaining(fmt.Sprintf("declined at %q", cmd.Label()), false)
+	}
+	m.settleGroup(m.indexOf(cmd))
+}
+
+// failGate fails the waiting gate because it cannot be answered, such as in
+// headless mode without --approve
+func (m *Model) failGate(err error) {
+	cmd := m.gate
+	if cmd == nil {
+		return
+	}
+	m.gate = nil
+	cmd.EndTime = time.Now()
+	cmd.Status = executor.StatusFailed
+	cmd.Error = err
+	cmd.ExitCode = 1
+	m.fail(cmd)
+
+	if m.logger != nil {
+		m.logger.LogCommandEnd(cmd)
+	}
+
+	m.SkipRemaining()
+	m.settleGroup(m.indexOf(cmd))
+}
@@ -0,0 +1,118 @@
+package app
+
+import "github.com/alameenkhader/lazycommands/internal/executor"
+
+// listRow is one line of the command list: a group header or a command
+type listRow struct {
+	group   int // Index into m.groups, or -1 for ungrouped commands
+	command int // Index into m.commands, or -1 for group headers
+}
+
+// isHeader reports whether the row is a group header
+func (r listRow) isHeader() bool {
+	return r.command < 0
+}
+
+// rows returns the lines of the command list. Commands in collapsed groups
+// are hidden behind their group header.
+func (m Model) rows() []listRow {
+	rows := make([]listRow, 0, len(m.commands)+len(m.groups))
+	for i := range m.commands {
+		g := m.groupOf(i)
+		if g < 0 {
+			rows = append(rows, listRow{group: -1, command: i})
+			continue
+		}
+		if i == m.groups[g].Start {
+			rows = append(rows, listRow{group: g, command: -1})
+		}
+		if !m.collapsed[g] {
+			rows = append(rows, listRow{group: g, command: i})
+		}
+	}
+	return rows
+}
+
+// groupOf returns the index of the group containing command i, or -1
+func (m Model) groupOf(i int) int {
+	for g, group := range m.groups {
+		if i >= group.Start && i < group.Start+len(group.Commands) {
+			return g
+		}
+	}
+	return -1
+}
+
+// selectedRow returns the selected line of the list
+func (m Model) selectedRow() (listRow, bool) {
+	rows := m.rows()
+	if m.selected < 0 || m.selected >= len(rows) {
+		return listRow{}, false
+	}
+	return rows[m.selected], true
+}
+
+// selectRow moves the selection to row, or to its group header when the
+// row is hidden in a collapsed group
+func (m *Model) selectRow(row listRow) {
+	for i, r := range m.rows() {
+		if r == row || (r.isHeader() && r.group == row.group && row.group >= 0 && m.collapsed[row.group]) {
+			m.selected = i
+			m.scrollList()
+			return
+		}
+	}
+}
+
+// selectCommand moves the selection to command i
+func (m *Model) selectCommand(i int) {
+	m.selectRow(listRow{group: m.groupOf(i), command: i})
+}
+
+// setCollapsed folds or unfolds group g, keeping the selection on the same
+// row, or on the header if the selected command is folded away
+func (m *Model) setCollapsed(g int, collapsed bool) {
+	if g < 0 || m.collapsed[g] == collapsed {
+		return
+	}
+	row, ok := m.selectedRow()
+	m.collapsed[g] = collapsed
+	if ok {
+		m.selectRow(row)
+	}
+	m.scrollList()
+}
+
+// settleGroup folds the group of command i once all its steps have
+// succeeded, and unfolds it when one fails
+func (m *Model) settleGroup(i int) {
+	g := m.groupOf(i)
+	if g < 0 {
+		return
+	}
+	switch m.groups[g].Status() {
+	case executor.StatusCompleted, executor.StatusCached:
+		m.setCollapsed(g, true)
+	case executor.StatusFailed:
+		m.setCollapsed(g, false)
+	}
+}
+
+// previewCommand returns the command whose output represents a row: the
+// command itself, or for a group the step that failed, is running, or ran last
+func (m Model) previewCommand(row listRow) *executor.Command {
+	if !row.isHeader() {
+		return m.commands[row.command]
+	}
+
+	var preview *executor.Command
+	for _, cmd := range m.groups[row.group].Commands {
+		switch {
+		case cmd.Status == executor.StatusFailed || cmd.Active():
+			return cmd
+		case preview == nil || !cmd.StartTime.IsZero():
+			preview = cmd
+		}
+	}
+	return preview
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errApprovalRequired fails approval gates that cannot be answered
+var errApprovalRequired = errors.New("approval required: pass --approve to approve gates without a terminal")
+
+// RunHeadless runs the commands in order without the terminal UI, printing
+// each command's output to out once it finishes. Approval gates are approved
+// with --approve and fail the run otherwise. The run's hooks run at the end.
+// The first signal on interrupt stops the run like quitting the interface,
+// leaving only the cleanup steps to run; a second one cancels those too.
+func RunHeadless(m Model, out io.Writer, interrupt <-chan os.Signal) Model {
+	for !m.abandoned {
+		select {
+		case <-interrupt:
+			m.interrupt()
+			continue
+		default:
+		}
+
+		i := m.nextRunnable()
+		if i < 0 {
+			break
+		}
+		cmd := m.commands[i]
+
+		if cmd.Pause != "" {
+			m.waitForApproval(i)
+			if !m.approve {
+				m.failGate(errApprovalRequired)
+				fmt.Fprintf(out, "%s %s: %v\n\n", ui.StatusIcon(cmd.Status), headlessLabel(cmd), cmd.Error)
+				continue
+			}
+			m.resolveGate(true, approvedByFlag)
+			fmt.Fprintf(out, "%s %s: approved by %s\n\n", ui.StatusIcon(cmd.Status), headlessLabel(cmd), approvedByFlag)
+			continue
+		}
+
+		fmt.Fprintf(out, "%s %s\n", ui.StatusIcon(executor.StatusRunning), headlessLabel(cmd))
+		// There is no keyboard to attach with, so input steps read end-of-file
+		cmd.AcceptInput = false
+		m.executing = i
+		var succeeded bool
+		switch msg := m.await(i, cmd, out, interrupt).(type) {
+		case executor.CommandCompletedMsg:
+			succeeded = m.finishCommand(msg)
+		case cachedMsg:
+			succeeded = m.applyCached(msg)
+		}
+
+		for _, line := range cmd.Output {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+
+		result := fmt.Sprintf("%s %s (%s", ui.StatusIcon(cmd.Status), headlessLabel(cmd), ui.FormatDuration(cmd))
+		switch {
+		case cmd.Status == executor.StatusCached:
+			result += ", cached"
+		case !succeeded:
+			result += fmt.Sprintf(", exit code %d", cmd.ExitCode)
+		}
+		fmt.Fprintf(out, "%s)\n\n", result)
+	}
+
+	if hooks := m.runHooks(); hooks != nil {
+		if done, ok := hooks().(hooksDoneMsg); ok && done.failed > 0 {
+			fmt.Fprintf(out, "%d run hook(s) failed; see the debug log\n\n", done.failed)
+		}
+	}
+	return m
+}
+
+// await runs command i and waits for its result, handling interrupts that
+// arrive in the meantime and telling on out if the command stops reading the
+// terminal
+func (m *Model) await(i int, cmd *executor.Command, out io.Writer, interrupt <-chan os.Signal) tea.Msg {
+	done := make(chan tea.Msg, 1)
+	start := m.withStepHooks(cmd, m.startCommand(i, cmd))
+	go func() {
+		done <- start()
+	}()
+
+	check := time.NewTicker(time.Second)
+	defer check.Stop()
+	noticed := false
+	for {
+		select {
+		case msg := <-done:
+			return msg
+		case <-interrupt:
+			m.interrupt()
+		case <-check.C:
+			if !noticed && cmd.StoppedOnTerminal() {
+				noticed = true
+				fmt.Fprintf(out, "    "+terminalStopNotice+"\n", "The step", "ctrl+c")
+			}
+		}
+	}
+}
+
+// interrupt stops the run and cancels the running command. Cleanup steps
+// keep running until a second interrupt.
+func (m *Model) interrupt() {
+	running := m.runningCommand()
+	if m.stopping {
+		m.abandoned = true
+		m.cancelActive()
+		return
+	}
+
+	m.stopRun()
+	if running == nil || !running.Always {
+		m.cancelActive()
+	}
+}
+
+// headlessLabel names a step in headless output, prefixed by its group
+func headlessLabel(cmd *executor.Command) string {
+	if cmd.Group != "" {
+		return cmd.Group + " › " + cmd.Label()
+	}
+	return cmd.Label()
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/hooks"
+	"github.com/alameenkhader/lazycommands/internal/log"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hooksDoneMsg reports how many of the run's hooks failed
+type hooksDoneMsg struct {
+	failed int
+}
+
+// withStepHooks runs the step's hooks once start has produced its result,
+// before the next step starts
+func (m *Model) withStepHooks(cmd *executor.Command, start tea.Cmd) tea.Cmd {
+	if cmd.Hooks.Empty() {
+		return start
+	}
+
+	logger, shell, dir := m.logger, m.hookShell(), m.workingDir
+	return func() tea.Msg {
+		msg := start()
+
+		event := hooks.Event{
+			Success: true,
+			Step:    cmd.Label(),
+			Command: cmd.Secrets.Mask(cmd.Raw),
+			Log:     logger.Path(),
+		}
+		switch msg := msg.(type) {
+		case executor.CommandCompletedMsg:
+			event.Success = msg.Error == nil
+			event.ExitCode = msg.ExitCode
+		case cachedMsg:
+			if msg.Err != nil {
+				event.Success = false
+				event.ExitCode = 1
+			}
+		}
+		event.Message = stepMessage(event)
+
+		fireHooks(logger, cmd, cmd.Hooks.For(event.Success), event, shell, dir)
+		return msg
+	}
+}
+
+// runHooks returns the tea.Cmd that runs the hooks for the finished run. A
+// stopped run has no outcome, so nothing runs.
+func (m *Model) runHooks() tea.Cmd {
+	if m.stopping || m.hooks.Empty() {
+		return nil
+	}
+
+	event := m.runEvent()
+	list := m.hooks.For(event.Success)
+	if len(list) == 0 {
+		return nil
+	}
+
+	logger, shell, dir := m.logger, m.hookShell(), m.startDir
+	return func() tea.Msg {
+		return hooksDoneMsg{failed: fireHooks(logger, nil, list, event, shell, dir)}
+	}
+}
+
+// runEvent describes how the run ended: the failed step or declined gate, or
+// how many steps completed
+func (m Model) runEvent() hooks.Event {
+	event := hooks.Event{Success: true, Log: m.LoggerPath()}
+
+	switch {
+	case m.failedCommand != nil:
+		cmd := m.failedCommand
+		event.Success = false
+		event.Step = cmd.Label()
+		event.Command = cmd.Secrets.Mask(cmd.Raw)
+		event.ExitCode = cmd.ExitCode
+		event.Message = fmt.Sprintf("%s failed with exit code %d", cmd.Label(), cmd.ExitCode)
+	case m.declined != nil:
+		cmd := m.declined
+		event.Success = false
+		event.Step = cmd.Label()
+		event.ExitCode = 1
+		event.Message = fmt.Sprintf("Declined at %q", cmd.Pause)
+	default:
+		completed := 0
+		for _, cmd := range m.commands {
+			if cmd.Status.Succeeded() {
+				completed++
+			}
+		}
+		event.Message = fmt.Sprintf("%d/%d steps completed", completed, len(m.commands))
+	}
+	return event
+}
+
+// hookShell returns the shell hook commands run with
+func (m Model) hookShell() string {
+	if m.shell != "" {
+		return m.shell
+	}
+	return executor.DefaultShell()
+}
+
+// stepMessage describes a finished step for notifications
+func stepMessage(e hooks.Event) string {
+	if e.Success {
+		return fmt.Sprintf("%s succeeded", e.Step)
+	}
+	return fmt.Sprintf("%s failed with exit code %d", e.Step, e.ExitCode)
+}
+
+// fireHooks runs hooks in order, logging each one, and returns how many failed.
+// A failed hook does not stop the ones after it.
+func fireHooks(logger *log.Logger, cmd *executor.Command, list []hooks.Hook, event hooks.Event, shell, dir string) int {
+	failed := 0
+	for _, h := range list {
+		output, err := h.Fire(event, shell, dir)
+		if err != nil {
+			failed++
+		}
+		logger.LogHook(cmd, event.Success, h, output, err)
+	}
+	return failed
+}
@@ -2,14 +2,55 @@ package app
 
 import (
 	"os"
+	"time"
 
+	"github.com/alameenkhader/lazycommands/internal/cache"
 	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/hooks"
 	"github.com/alameenkhader/lazycommands/internal/keys"
 	"github.com/alameenkhader/lazycommands/internal/log"
+	"github.com/alameenkhader/lazycommands/internal/record"
+	"github.com/alameenkhader/lazycommands/internal/secrets"
+	"github.com/alameenkhader/lazycommands/internal/ui"
+	"github.com/alameenkhader/lazycommands/internal/watch"
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// viewMode is the screen currently shown
+type viewMode int
+
+const (
+	modeList    viewMode = iota // Command list
+	modeOutput                  // Output viewer for the selected command
+	modeFailure                 // Failure details and output of a failed command
+)
+
+// minSplitWidth is the narrowest terminal that shows the output preview beside
+// the list in fullscreen mode
+const minSplitWidth = 80
+
+// wheelLines is how far one mouse wheel step scrolls the output
+const wheelLines = 3
+
+// Options configures a Model
+type Options struct {
+	KeyMap     keys.KeyMap
+	Shell      string           // Shell used to run commands (empty for $SHELL)
+	Log        log.Settings     // Debug log format, location and retention
+	Fullscreen bool             // Running in the alternate screen
+	Confirm    bool             // Review the steps before running them
+	Approve    bool             // Approve every approval gate without asking
+	Watcher    *watch.Watcher   // Rerun the commands when watched files change
+	Cache      *cache.Cache     // Results of steps with inputs (nil disables caching)
+	Hooks      hooks.Set        // Run once the whole run succeeds or fails
+	Secrets    *secrets.Masker  // Secrets masked in output and logs (nil for none)
+	Replay     string           // Log the finished steps were read from; nothing runs
+	Recorder   *record.Recorder // Records the steps' output (nil for none)
+}
+
 // Model represents the Bubble Tea application state
 type Model struct {
 	// Core state
@@ -17,20 +58,65 @@ type Model struct {
 	executing     int               // Index of currently executing command (-1 if none)
 	failedCommand *executor.Command // The command that failed (if any)
 	workingDir    string            // Current working directory for command execution
+	shell         string            // Shell used to run commands
 	logger        *log.Logger       // Debug logger for command execution
+	recorder      *record.Recorder  // Recording of the steps' output (nil when not recording)
+	gate          *executor.Command // Approval gate waiting for an answer (if any)
+	declined      *executor.Command // Approval gate that was declined (if any)
+	approve       bool              // Approve gates without asking
+	cache         *cache.Cache      // Cached step results (nil when disabled)
+	hooks         hooks.Set         // Run when the run finishes
+	secrets       *secrets.Masker   // Secrets masked in output and logs
+	stopping      bool              // The run was stopped; only cleanup steps are left to run
+	abandoned     bool              // Stopped again while cleaning up; nothing else runs
 
 	// UI state
-	width   int
-	height  int
-	ready   bool
-	spinner spinner.Model
+	width        int
+	height       int
+	ready        bool
+	spinner      spinner.Model
+	mode         viewMode
+	selected     int  // Index of the selected row in the list
+	manualSelect bool // User moved the selection; stop following the running command
+	listOffset   int  // Index of the first row shown when the list is scrolled
+	groups       []executor.Group
+	collapsed    []bool // Fold state of each group
+	fullscreen   bool
+	viewer       ui.Viewer
+	help         help.Model
+	showHelp     bool   // Full help overlay is visible
+	reviewing    bool   // Pre-run review: nothing runs until it is confirmed
+	replay       string // Log being viewed; the steps are read-only and nothing runs
+	notice       string // Message shown in place of the list's help bar until the next key
+
+	// Steps stopped reading the terminal
+	stopCheck   time.Time         // Last check of the running step's processes
+	stopNoticed *executor.Command // Step already reported as stopped
+
+	// Editing the pending queue
+	input       textinput.Model
+	editing     editKind
+	editTarget  *executor.Command // Step being edited
+	nextID      int               // ID for the next added step
+	queueEdited bool              // Queue changed during the run
+
+	// Watch mode
+	watcher      *watch.Watcher // Watched files (nil when not watching)
+	run          int            // Number of the current run, counting from 1
+	trigger      string         // File whose change started the current run
+	rerunTrigger string         // Change waiting for the cancelled command to stop
+	startDir     string         // Working directory each run starts in
+
+	// Input forwarded to the running command
+	stdin    textinput.Model
+	attached *executor.Command // Command receiving keyboard input (if any)
 
 	// Keyboard
 	keys keys.KeyMap
 }
 
 // NewModel creates a new Model with the given commands
-func NewModel(commands []*executor.Command) Model {
+func NewModel(commands []*executor.Command, opts Options) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Line
 	s.Style = s.Style.Foreground(s.Style.GetForeground())
@@ -42,36 +128,92 @@ func NewModel(commands []*executor.Command) Model {
 	}
 
 	// Create logger (continue if it fails)
-	logger, err := log.NewLogger()
-	if err != nil {
-		// Log creation failed, but continue without logging
-		logger = nil
+	var logger *log.Logger
+	if !opts.Log.Disabled {
+		logger, err = log.NewLogger(opts.Log)
+		if err != nil {
+			// Log creation failed, but continue without logging
+			logger = nil
+		}
+	}
+
+	// Secret steps register their output even when no other secrets are given
+	masker := opts.Secrets
+	if masker == nil {
+		masker = secrets.New()
+	}
+	logger.SetSecrets(masker)
+	for _, cmd := range commands {
+		cmd.Secrets = masker
 	}
+	logger.LogCommands(commands)
+
+	keyMap := opts.KeyMap
+	groups := executor.Groups(commands)
 
 	return Model{
 		commands:      commands,
 		executing:     -1,
 		failedCommand: nil,
 		workingDir:    cwd,
+		shell:         opts.Shell,
 		logger:        logger,
-		keys:          keys.DefaultKeyMap(),
+		recorder:      opts.Recorder,
+		approve:       opts.Approve,
+		cache:         opts.Cache,
+		hooks:         opts.Hooks,
+		secrets:       masker,
+		keys:          keyMap,
 		ready:         false,
 		spinner:       s,
+		mode:          modeList,
+		groups:        groups,
+		collapsed:     make([]bool, len(groups)),
+		fullscreen:    opts.Fullscreen,
+		reviewing:     opts.Confirm,
+		replay:        opts.Replay,
+		viewer:        ui.NewViewer(keyMap),
+		help:          newHelp(),
+		input:         newInput(),
+		stdin:         newInput(),
+		nextID:        len(commands),
+		watcher:       opts.Watcher,
+		run:           1,
+		startDir:      cwd,
 	}
 }
 
+// startMsg asks Update to begin executing commands. Init cannot start them
+// itself because it receives a copy of the model and state changes would be lost.
+type startMsg struct{}
+
+// newHelp creates the key hint renderer using the current theme
+func newHelp() help.Model {
+	h := help.New()
+	h.Styles.ShortKey = ui.HelpKeyStyle
+	h.Styles.ShortDesc = ui.HelpDescStyle
+	h.Styles.ShortSeparator = ui.HelpDescStyle
+	h.Styles.Ellipsis = ui.HelpDescStyle
+	h.Styles.FullKey = ui.HelpKeyStyle
+	h.Styles.FullDesc = ui.HelpDescStyle
+	h.Styles.FullSeparator = ui.HelpDescStyle
+	return h
+}
+
 // Init initializes the model and starts command execution
 func (m Model) Init() tea.Cmd {
 	// Start executing the first command and the spinner
 	return tea.Batch(
-		(&m).executeNext(),
+		func() tea.Msg { return startMsg{} },
 		m.spinner.Tick,
+		m.waitForChange(),
 	)
 }
 
-// ExitCode returns the appropriate exit code based on command results
+// ExitCode returns the appropriate exit code based on command results.
+// A declined approval gate counts as a failed run.
 func (m Model) ExitCode() int {
-	if m.failedCommand != nil {
+	if m.failedCommand != nil || m.declined != nil {
 		return 1
 	}
 	for _, cmd := range m.commands {
@@ -85,18 +227,29 @@ func (m Model) ExitCode() int {
 // AllCommandsDone checks if all commands have finished (completed, failed, or skipped)
 func (m Model) AllCommandsDone() bool {
 	for _, cmd := range m.commands {
-		if cmd.Status == executor.StatusPending || cmd.Status == executor.StatusRunning {
+		switch cmd.Status {
+		case executor.StatusPending, executor.StatusRunning, executor.StatusWaiting, executor.StatusPaused:
 			return false
 		}
 	}
 	return true
 }
 
-// SkipRemaining marks all pending commands as skipped
+// SkipRemaining marks all pending commands as skipped after a failure.
+// Commands whose condition checks for failures stay pending so the condition
+// decides whether they run.
 func (m *Model) SkipRemaining() {
+	m.skipRemaining("a previous step failed", true)
+}
+
+// skipRemaining marks pending commands as skipped for the given reason,
+// leaving commands whose condition checks for failures pending if
+// keepConditional is set. Cleanup steps always stay pending.
+func (m *Model) skipRemaining(reason string, keepConditional bool) {
 	for _, cmd := range m.commands {
-		if cmd.Status == executor.StatusPending {
+		if cmd.Status == executor.StatusPending && !cmd.Always && !(keepConditional && reactsToFailure(cmd)) {
 			cmd.Status = executor.StatusSkipped
+			cmd.SkipReason = reason
 
 			// Log skipped command
 			if m.logger != nil {
@@ -106,11 +259,31 @@ func (m *Model) SkipRemaining() {
 	}
 }
 
+// Declined returns the approval gate that was declined, or nil
+func (m Model) Declined() *executor.Command {
+	return m.declined
+}
+
+// Stopped reports whether the run was stopped before it finished
+func (m Model) Stopped() bool {
+	return m.stopping
+}
+
+// Reviewing reports whether the pre-run review was still open, so nothing ran
+func (m Model) Reviewing() bool {
+	return m.reviewing
+}
+
 // Commands returns the list of commands
 func (m Model) Commands() []*executor.Command {
 	return m.commands
 }
 
+// Width returns the terminal width reported to the program
+func (m Model) Width() int {
+	return m.width
+}
+
 // Spinner returns the spinner model
 func (m Model) Spinner() spinner.Model {
 	return m.spinner
@@ -124,9 +297,13 @@ func (m Model) LoggerPath() string {
 	return m.logger.Path()
 }
 
-// CloseLogger closes the logger if it exists
+// CloseLogger closes the logger if it exists. If the queue was edited, the
+// final command list is logged first.
 func (m *Model) CloseLogger() {
 	if m.logger != nil {
+		if m.queueEdited {
+			m.logger.LogCommandList(m.commands)
+		}
 		m.logger.Close()
 	}
 }
@@ -0,0 +1,310 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/log"
+	"github.com/alameenkhader/lazycommands/internal/parser"
+	"github.com/alameenkhader/lazycommands/internal/ui"
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editKind is the queue change being typed into the list's text input
+type editKind int
+
+const (
+	editNone    editKind = iota
+	editInsert           // Adding a new step after the selection
+	editCommand          // Changing the text of a pending step
+)
+
+// newInput creates the text input used to add and edit steps
+func newInput() textinput.Model {
+	input := textinput.New()
+	input.Cursor.SetMode(cursor.CursorStatic)
+	input.PromptStyle = ui.PromptStyle
+	return input
+}
+
+// selectedCommand returns the index of the command on the selected row.
+// A group header stands for the last command of its group.
+func (m Model) selectedCommand() (int, bool) {
+	row, ok := m.selectedRow()
+	if !ok {
+		return 0, false
+	}
+	if row.isHeader() {
+		g := m.groups[row.group]
+		return g.Start + len(g.Commands) - 1, true
+	}
+	return row.command, true
+}
+
+// selectedPending returns the index of the selected command if it has not started
+func (m Model) selectedPending() (int, error) {
+	i, ok := m.selectedCommand()
+	if !ok {
+		return 0, errors.New("no step selected")
+	}
+	row, _ := m.selectedRow()
+	if row.isHeader() {
+		return 0, errors.New("select a step inside the group")
+	}
+	if m.commands[i].Status != executor.StatusPending {
+		return 0, fmt.Errorf("only pending steps can be changed (%s is %s)", m.commands[i].Label(), strings.ToLower(m.commands[i].Status.String()))
+	}
+	return i, nil
+}
+
+// firstPending returns the index where the trailing run of pending commands
+// begins. New steps can only be added from there on.
+func (m Model) firstPending() int {
+	first := len(m.commands)
+	for i := len(m.commands) - 1; i >= 0; i-- {
+		if m.commands[i].Status != executor.StatusPending {
+			break
+		}
+		first = i
+	}
+	return first
+}
+
+// startInsert opens the text input for a step to add after the selection
+func (m *Model) startInsert() tea.Cmd {
+	if m.AllCommandsDone() || m.failedCommand != nil {
+		m.notice = "The run has finished; steps can no longer be added"
+		return nil
+	}
+	m.editing = editInsert
+	m.input.Prompt = "Add step: "
+	m.input.SetValue("")
+	return m.input.Focus()
+}
+
+// startEdit opens the text input with the selected pending step's command
+func (m *Model) startEdit() tea.Cmd {
+	i, err := m.selectedPending()
+	if err != nil {
+		m.notice = notice(err)
+		return nil
+	}
+
+	cmd := m.commands[i]
+	if strings.Contains(cmd.Raw, "\n") {
+		m.notice = "Multi-line commands cannot be edited inline"
+		return nil
+	}
+
+	m.editing = editCommand
+	m.editTarget = cmd
+	m.input.Prompt = "Edit: "
+	m.input.SetValue(cmd.Raw)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+// updateInput handles key presses while the text input has focus
+func (m Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		(&m).stopInput()
+		return m, nil
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.input.Value())
+		kind, target := m.editing, m.editTarget
+		(&m).stopInput()
+		if value == "" {
+			return m, nil
+		}
+
+		var err error
+		switch kind {
+		case editInsert:
+			err = (&m).insertCommand(value)
+		case editCommand:
+			err = (&m).editCommand(target, value)
+		}
+		if err != nil {
+			m.notice = notice(err)
+		}
+		(&m).syncPreview()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// stopInput closes the text input
+func (m *Model) stopInput() {
+	m.editing = editNone
+	m.editTarget = nil
+	m.input.Blur()
+}
+
+// parseStep parses typed text as a single step, accepting a "name: " prefix
+func parseStep(text string) (parser.Entry, error) {
+	entries, err := parser.Parse(strings.NewReader(text))
+	if err != nil {
+		return parser.Entry{}, err
+	}
+	if len(entries) != 1 {
+		return parser.Entry{}, errors.New("enter a single command")
+	}
+	return entries[0], nil
+}
+
+// insertCommand adds a pending step after the selected command, or after the
+// running command if the selection is on one that already finished. The new
+// step joins the group of the step before it.
+func (m *Model) insertCommand(text string) error {
+	entry, err := parseStep(text)
+	if err != nil {
+		return err
+	}
+
+	pos := m.firstPending()
+	if i, ok := m.selectedCommand(); ok && i+1 > pos {
+		pos = i + 1
+	}
+
+	cmd := executor.NewCommand(m.nextID, entry.Raw)
+	m.nextID++
+	cmd.Name = entry.Name
+	cmd.Secrets = m.secrets
+	if pos > 0 {
+		cmd.Group = m.commands[pos-1].Group
+	}
+
+	m.commands = append(m.commands[:pos], append([]*executor.Command{cmd}, m.commands[pos:]...)...)
+	m.regroup()
+	if g := m.groupOf(pos); g >= 0 {
+		m.setCollapsed(g, false)
+	}
+	m.selectCommand(pos)
+	m.manualSelect = true
+	m.logQueue(log.QueueInsert, cmd, pos+1, "")
+	return nil
+}
+
+// editCommand replaces the command text of a step that has not started yet
+func (m *Model) editCommand(cmd *executor.Command, text string) error {
+	if cmd == nil || cmd.Status != executor.StatusPending {
+		return errors.New("the step started before the edit was saved")
+	}
+
+	entry, err := parseStep(text)
+	if err != nil {
+		return err
+	}
+	if entry.Raw == cmd.Raw && (entry.Name == "" || entry.Name == cmd.Name) {
+		return nil
+	}
+
+	previous := cmd.Raw
+	cmd.Raw = entry.Raw
+	if entry.Name != "" {
+		cmd.Name = entry.Name
+	}
+	m.logQueue(log.QueueEdit, cmd, m.indexOf(cmd)+1, previous)
+	return nil
+}
+
+// deleteSelected removes the selected pending step from the queue
+func (m *Model) deleteSelected() {
+	i, err := m.selectedPending()
+	if err != nil {
+		m.notice = notice(err)
+		return
+	}
+
+	cmd := m.commands[i]
+	m.commands = append(m.commands[:i], m.commands[i+1:]...)
+	m.regroup()
+	m.selected = min(m.selected, len(m.rows())-1)
+	m.scrollList()
+	m.logQueue(log.QueueDelete, cmd, 0, "")
+}
+
+// moveSelected moves the selected pending step up or down by one. At the edge
+// of a group the step first leaves the group, or joins the neighbouring one,
+// so groups stay contiguous.
+func (m *Model) moveSelected(delta int) {
+	i, err := m.selectedPending()
+	if err != nil {
+		m.notice = notice(err)
+		return
+	}
+
+	j := i + delta
+	if j < 0 || j >= len(m.commands) {
+		return
+	}
+
+	cmd, neighbor := m.commands[i], m.commands[j]
+	switch {
+	case neighbor.Group != cmd.Group:
+		cmd.Group = neighbor.Group
+	case neighbor.Status != executor.StatusPending:
+		m.notice = "Steps cannot be moved before ones that already started"
+		return
+	default:
+		m.commands[i], m.commands[j] = neighbor, cmd
+		i = j
+	}
+
+	m.regroup()
+	if g := m.groupOf(i); g >= 0 {
+		m.setCollapsed(g, false)
+	}
+	m.selectCommand(i)
+	m.manualSelect = true
+	m.logQueue(log.QueueMove, cmd, i+1, "")
+}
+
+// regroup rebuilds the groups after the queue changes, keeping each group's fold state
+func (m *Model) regroup() {
+	folded := make(map[string]bool)
+	for g, group := range m.groups {
+		folded[group.Name] = m.collapsed[g]
+	}
+
+	m.groups = executor.Groups(m.commands)
+	m.collapsed = make([]bool, len(m.groups))
+	for g, group := range m.groups {
+		m.collapsed[g] = folded[group.Name]
+	}
+}
+
+// indexOf returns the position of cmd in the list, or -1
+func (m Model) indexOf(cmd *executor.Command) int {
+	for i, c := range m.commands {
+		if c == cmd {
+			return i
+		}
+	}
+	return -1
+}
+
+// notice turns an error into a sentence for the list's notice line
+func notice(err error) string {
+	msg := err.Error()
+	if msg == "" {
+		return msg
+	}
+	return strings.ToUpper(msg[:1]) + msg[1:]
+}
+
+// logQueue records a queue change so the final command list is auditable
+func (m *Model) logQueue(action log.QueueAction, cmd *executor.Command, position int, previous string) {
+	m.queueEdited = true
+	if m.logger != nil {
+		m.logger.LogQueueEdit(action, cmd, position, previous)
+	}
+}
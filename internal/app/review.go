@@ -0,0 +1,109 @@
+package app
+
+import (
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// skipNotSelected is the skip reason for steps turned off in the review
+const skipNotSelected = "not selected"
+
+// handleReviewKey handles the keys specific to the pre-run review. It reports
+// false for keys the list handles itself, such as moving the selection.
+func (m *Model) handleReviewKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, m.keys.ToggleStep):
+		m.toggleSelected()
+	case key.Matches(msg, m.keys.StartHere):
+		m.startFromSelected()
+	case key.Matches(msg, m.keys.Continue):
+		return m.confirmReview(), true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// toggleSelected turns the selected step off or back on. On a group header it
+// turns the whole group off, or back on if it is already off.
+func (m *Model) toggleSelected() {
+	row, ok := m.selectedRow()
+	if !ok {
+		return
+	}
+
+	var targets []*executor.Command
+	if row.isHeader() {
+		targets = m.groups[row.group].Commands
+	} else {
+		targets = []*executor.Command{m.commands[row.command]}
+	}
+
+	skip := false
+	for _, cmd := range targets {
+		if cmd.Status == executor.StatusPending {
+			skip = true
+		}
+	}
+	for _, cmd := range targets {
+		setSelected(cmd, !skip)
+	}
+}
+
+// startFromSelected turns off every step before the selected one and turns
+// the selected step on. Steps after it keep their selection.
+func (m *Model) startFromSelected() {
+	row, ok := m.selectedRow()
+	if !ok {
+		return
+	}
+
+	start := row.command
+	if row.isHeader() {
+		start = m.groups[row.group].Start
+	}
+	for _, cmd := range m.commands[:start] {
+		setSelected(cmd, false)
+	}
+	setSelected(m.commands[start], true)
+}
+
+// setSelected marks a step to run or to be skipped
+func setSelected(cmd *executor.Command, selected bool) {
+	if selected {
+		cmd.Status = executor.StatusPending
+		cmd.SkipReason = ""
+		return
+	}
+	cmd.Status = executor.StatusSkipped
+	cmd.SkipReason = skipNotSelected
+}
+
+// confirmReview ends the review and starts running the selected steps
+func (m *Model) confirmReview() tea.Cmd {
+	m.reviewing = false
+	m.manualSelect = false
+
+	for _, cmd := range m.commands {
+		if cmd.Status == executor.StatusSkipped && m.logger != nil {
+			m.logger.LogCommandSkipped(cmd)
+		}
+	}
+
+	if m.AllCommandsDone() {
+		return m.runDone()
+	}
+	return m.executeNext()
+}
+
+// selectedCount returns how many steps are set to run
+func (m Model) selectedCount() int {
+	count := 0
+	for _, cmd := range m.commands {
+		if cmd.Status == executor.StatusPending {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+)
+
+// runningCommand returns the command that is running or paused, or nil
+func (m Model) runningCommand() *executor.Command {
+	if m.executing < 0 || m.executing >= len(m.commands) {
+		return nil
+	}
+	if cmd := m.commands[m.executing]; cmd.Active() {
+		return cmd
+	}
+	return nil
+}
+
+// terminalStopNotice explains a step that stopped reading the terminal
+const terminalStopNotice = "%s stopped to read the terminal, which steps cannot use; press %s to stop the run"
+
+// checkTerminalStop tells once when the running step has stopped reading the
+// terminal, since it would otherwise wait forever. Processes are only checked
+// once a second.
+func (m *Model) checkTerminalStop() {
+	cmd := m.runningCommand()
+	if cmd == nil || cmd == m.stopNoticed || time.Since(m.stopCheck) < time.Second {
+		return
+	}
+	m.stopCheck = time.Now()
+	if cmd.StoppedOnTerminal() {
+		m.stopNoticed = cmd
+		m.notice = fmt.Sprintf(terminalStopNotice, cmd.Label(), m.keys.Quit.Help().Key)
+	}
+}
+
+// toggleSuspend pauses the running command, or resumes it if it is paused
+func (m *Model) toggleSuspend() {
+	cmd := m.runningCommand()
+	if cmd == nil {
+		return
+	}
+
+	if cmd.Status == executor.StatusPaused {
+		if err := cmd.Resume(); err != nil {
+			m.notice = notice(err)
+			return
+		}
+		if m.logger != nil {
+			m.logger.LogCommandResumed(cmd)
+		}
+		return
+	}
+
+	if err := cmd.Suspend(); err != nil {
+		m.notice = notice(err)
+		return
+	}
+	if m.logger != nil {
+		m.logger.LogCommandSuspended(cmd)
+	}
+}
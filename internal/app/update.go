@@ -1,7 +1,10 @@
 package app
 
 import (
+	"fmt"
+
 	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/ui"
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -15,60 +18,95 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.ready = true
+		m.help.Width = msg.Width
+		m.input.Width = msg.Width - 12 // Leave room for the prompt
+		m.stdin.Width = msg.Width / 2
+		(&m).resizeViewer()
+		(&m).scrollList()
+		(&m).syncPreview()
 		return m, nil
 
 	case tea.KeyMsg:
-		// Handle quit
-		if key.Matches(msg, m.keys.Quit) {
-			// Cancel any running commands
-			for _, cmd := range m.commands {
-				if cmd.Status == executor.StatusRunning {
-					cmd.Cancel()
-				}
-			}
-			return m, tea.Quit
+		return m.handleKey(msg)
+
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
+	case startMsg:
+		// The review starts the run once it is confirmed; a replayed run is over
+		if m.reviewing || m.replay != "" {
+			return m, nil
 		}
+		return m, (&m).executeNext()
 
 	case executor.TickMsg:
 		// Periodic refresh to show streaming output
+		if m.mode != modeList || m.splitView() {
+			m.viewer.Refresh()
+		}
+		(&m).checkTerminalStop()
 		// Only keep ticking if a command is running
 		if m.executing >= 0 {
 			return m, executor.Ticker()
 		}
 		return m, nil
 
+	case ui.NoticeExpiredMsg:
+		m.viewer.ExpireNotice(msg)
+		return m, nil
+
+	case hooksDoneMsg:
+		if msg.failed > 0 {
+			m.notice = fmt.Sprintf("%d run hook(s) failed; see the debug log", msg.failed)
+		}
+		return m, nil
+
+	case watchMsg:
+		return m, tea.Batch((&m).changed(msg.file), m.waitForChange())
+
+	case cachedMsg:
+		if m.rerunTrigger != "" {
+			trigger := m.rerunTrigger
+			m.rerunTrigger = ""
+			return m, (&m).rerun(trigger)
+		}
+		if !(&m).applyCached(msg) {
+			(&m).showFailure()
+			return m, (&m).executeNext()
+		}
+		if m.AllCommandsDone() {
+			return m, (&m).runDone()
+		}
+		return m, (&m).executeNext()
+
 	case executor.CommandCompletedMsg:
+		// The run was cancelled for a rerun, which starts once the command has stopped
+		if m.rerunTrigger != "" {
+			trigger := m.rerunTrigger
+			m.rerunTrigger = ""
+			return m, (&m).rerun(trigger)
+		}
 		if msg.Index >= 0 && msg.Index < len(m.commands) {
 			cmd := m.commands[msg.Index]
-
-			// Update working directory if changed
-			if msg.NewDir != "" {
-				m.workingDir = msg.NewDir
+			if m.attached == cmd {
+				(&m).detach()
 			}
 
-			if msg.Error != nil {
-				// Command failed - stop execution and show error
-				cmd.Status = executor.StatusFailed
-				cmd.Error = msg.Error
-				cmd.ExitCode = msg.ExitCode
-				m.executing = -1
-				m.failedCommand = cmd
-
-				// Skip all remaining commands
-				(&m).SkipRemaining()
-
-				// Don't quit immediately - let user see the error output
-				return m, nil
+			if !(&m).finishCommand(msg) {
+				// Don't quit immediately - let user see the error output.
+				// Cleanup and conditional steps still get their turn.
+				(&m).showFailure()
+				return m, (&m).executeNext()
 			}
 
-			// Command succeeded
-			cmd.Status = executor.StatusCompleted
-			cmd.ExitCode = msg.ExitCode
-			m.executing = -1
+			// Pick up the last lines of output if the command is being viewed
+			if m.mode != modeList || m.splitView() {
+				m.viewer.Refresh()
+			}
 
 			// Check if all commands are done
 			if m.AllCommandsDone() {
-				return m, tea.Quit
+				return m, (&m).runDone()
 			}
 
 			// Execute next command
@@ -81,6 +119,235 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		return m, cmd
 	}
+}
+
+// handleKey dispatches key presses according to the current mode
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	searching := m.mode != modeList && m.viewer.Searching()
+	typing := searching || m.editing != editNone || m.attached != nil
+
+	// Handle quit (only ctrl+c while typing a search query, a step or input)
+	if key.Matches(msg, m.keys.Quit) && (!typing || msg.Type == tea.KeyCtrlC) {
+		return m, (&m).quit()
+	}
+
+	// The help overlay covers the screen until it is dismissed
+	if m.showHelp {
+		if key.Matches(msg, m.keys.Help) || key.Matches(msg, m.keys.Back) {
+			m.showHelp = false
+		}
+		return m, nil
+	}
+	if !typing && key.Matches(msg, m.keys.Help) {
+		m.showHelp = true
+		return m, nil
+	}
+
+	// A waiting approval gate is answered from the command list. The output
+	// screens keep their own keys, such as n for the next search match.
+	if m.gate != nil && !typing && m.mode == modeList {
+		if cmd, ok := (&m).handleGateKey(msg); ok {
+			return m, cmd
+		}
+	}
+
+	// Keys go to the attached command until it is detached
+	if m.attached != nil {
+		return m.updateAttached(msg)
+	}
+
+	// The running command is paused and resumed from any screen. Nothing
+	// runs in a replayed run.
+	if !typing && m.replay == "" && key.Matches(msg, m.keys.Suspend) {
+		(&m).toggleSuspend()
+		return m, nil
+	}
+	if !typing && m.replay == "" && key.Matches(msg, m.keys.Attach) {
+		return m, (&m).attach()
+	}
+
+	if m.mode == modeList {
+		if m.editing != editNone {
+			return m.updateInput(msg)
+		}
+		m.notice = ""
+
+		if m.reviewing {
+			if cmd, ok := (&m).handleReviewKey(msg); ok {
+				(&m).syncPreview()
+				return m, cmd
+			}
+		}
+
+		last := len(m.rows()) - 1
+		switch {
+		case key.Matches(msg, m.keys.Up):
+			m.selected = max(m.selected-1, 0)
+			m.manualSelect = true
+		case key.Matches(msg, m.keys.Down):
+			m.selected = min(m.selected+1, last)
+			m.manualSelect = true
+		case key.Matches(msg, m.keys.PageUp):
+			m.selected = max(m.selected-m.listRows(), 0)
+			m.manualSelect = true
+		case key.Matches(msg, m.keys.PageDown):
+			m.selected = min(m.selected+m.listRows(), last)
+			m.manualSelect = true
+		case key.Matches(msg, m.keys.Top):
+			m.selected = 0
+			m.manualSelect = true
+		case key.Matches(msg, m.keys.Bottom):
+			m.selected = last
+			m.manualSelect = true
+		case key.Matches(msg, m.keys.Fold):
+			// Folding from a step folds the group it belongs to
+			if row, ok := m.selectedRow(); ok && row.group >= 0 {
+				(&m).setCollapsed(row.group, !m.collapsed[row.group])
+			}
+		case key.Matches(msg, m.keys.Open):
+			if row, ok := m.selectedRow(); ok {
+				if row.isHeader() {
+					(&m).setCollapsed(row.group, !m.collapsed[row.group])
+					break
+				}
+				(&m).openOutput(m.commands[row.command])
+				return m, nil
+			}
+		case m.reviewing || m.replay != "":
+			// The queue is edited with the review keys until the run starts,
+			// and a replayed run cannot be edited
+		case key.Matches(msg, m.keys.Insert):
+			return m, (&m).startInsert()
+		case key.Matches(msg, m.keys.Edit):
+			return m, (&m).startEdit()
+		case key.Matches(msg, m.keys.Delete):
+			(&m).deleteSelected()
+		case key.Matches(msg, m.keys.MoveUp):
+			(&m).moveSelected(-1)
+		case key.Matches(msg, m.keys.MoveDown):
+			(&m).moveSelected(1)
+		}
+		(&m).scrollList()
+		(&m).syncPreview()
+		return m, nil
+	}
+
+	// Output and failure modes
+	m.notice = ""
+	if !searching && key.Matches(msg, m.keys.Back) {
+		m.mode = modeList
+		(&m).syncPreview()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.viewer, cmd = m.viewer.Update(msg)
+	return m, cmd
+}
+
+// handleMouse selects commands on click and scrolls with the wheel. Mouse
+// events are only enabled in fullscreen mode, where screen rows map to the view.
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.showHelp || m.editing != editNone || m.attached != nil || msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+
+	delta := 0
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		delta = -1
+	case tea.MouseButtonWheelDown:
+		delta = 1
+	}
+
+	// Output and failure modes: the wheel scrolls the output
+	if m.mode != modeList {
+		if delta != 0 && !m.viewer.Searching() {
+			m.viewer.Scroll(delta * wheelLines)
+		}
+		return m, nil
+	}
+
+	// Over the preview panel, the wheel scrolls the selected command's output
+	if m.splitView() && msg.X > m.layout().LeftWidth() {
+		if delta != 0 {
+			m.viewer.Scroll(delta * wheelLines)
+		}
+		return m, nil
+	}
 
+	// Over the list, the wheel moves the selection
+	rows := m.rows()
+	if delta != 0 {
+		m.selected = max(min(m.selected+delta, len(rows)-1), 0)
+		m.manualSelect = true
+		(&m).scrollList()
+		(&m).syncPreview()
+		return m, nil
+	}
+
+	if msg.Button != tea.MouseButtonLeft {
+		return m, nil
+	}
+	i, ok := m.rowAt(msg.Y)
+	if !ok {
+		return m, nil
+	}
+	m.selected = i
+	m.manualSelect = true
+
+	// Clicking a group header folds or unfolds it
+	if row := rows[i]; row.isHeader() {
+		(&m).setCollapsed(row.group, !m.collapsed[row.group])
+		(&m).syncPreview()
+		return m, nil
+	}
+
+	// Failed steps open their failure details; other steps show their output
+	// in the preview, or full screen when there is no room for it
+	cmd := m.commands[rows[i].command]
+	(&m).scrollList()
+	if cmd.Status == executor.StatusFailed || !m.splitView() {
+		(&m).openOutput(cmd)
+		return m, nil
+	}
+	(&m).syncPreview()
 	return m, nil
 }
+
+// openOutput shows the output viewer for a command, with failure details if it failed
+func (m *Model) openOutput(cmd *executor.Command) {
+	m.mode = modeOutput
+	if cmd.Status == executor.StatusFailed {
+		m.mode = modeFailure
+	}
+	m.viewer.SetSize(m.width, m.viewerHeight(cmd))
+	m.viewer.SetCommand(cmd)
+}
+
+// syncPreview points the output preview of the split list at the selected row
+func (m *Model) syncPreview() {
+	if m.mode != modeList || !m.splitView() {
+		return
+	}
+	row, ok := m.selectedRow()
+	if !ok {
+		return
+	}
+	cmd := m.previewCommand(row)
+	if cmd == nil {
+		return
+	}
+	layout := m.layout()
+	// The preview panel has one column of padding on each side and a header
+	// above the output
+	m.viewer.SetSize(layout.RightWidth()-2, layout.Height-lineCount(m.previewHeader(cmd)))
+	m.viewer.SetCommand(cmd)
+}
+
+// resizeViewer fits the output viewer between the header and footer
+func (m *Model) resizeViewer() {
+	if cmd := m.viewer.Command(); cmd != nil {
+		m.viewer.SetSize(m.width, m.viewerHeight(cmd))
+	}
+}
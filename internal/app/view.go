@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/alameenkhader/lazycommands/internal/executor"
 	"github.com/alameenkhader/lazycommands/internal/ui"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // View renders the UI
@@ -13,97 +16,395 @@ func (m Model) View() string {
 		return "Initializing..."
 	}
 
-	// If there's a failed command, show its output
-	if m.failedCommand != nil {
-		return m.renderFailedCommandOutput()
+	if m.showHelp {
+		return m.renderHelp()
+	}
+
+	// Show the output viewer (with failure details if the command failed)
+	if m.mode != modeList && m.viewer.Command() != nil {
+		return m.renderOutput()
 	}
 
 	// Otherwise, show the command list
 	return m.renderCommandList()
 }
 
-// renderCommandList renders the list of commands with their status icons
+// renderCommandList renders the list of commands with their status icons. Wide
+// fullscreen terminals also show the selected command's output beside the list.
 func (m Model) renderCommandList() string {
 	var b strings.Builder
 
 	// b.WriteString(ui.TitleStyle.Render("LazyCommands") + "\n\n")
 
-	for i, cmd := range m.commands {
-		// Check if this is the currently running command
-		isRunning := (i == m.executing)
-		spinnerView := ""
-		if isRunning {
-			spinnerView = m.spinner.View()
-		}
-		line := ui.FormatCommandLineWithSpinner(cmd, false, spinnerView)
-		b.WriteString(line + "\n")
+	if m.splitView() {
+		layout := m.layout()
+		b.WriteString(layout.Render(m.renderRows(layout.LeftWidth()), m.renderPreview()) + "\n")
+	} else {
+		b.WriteString(m.renderRows(m.width))
 	}
 
-	// Add keyboard hints at the bottom
+	// Add keyboard hints at the bottom, or the step being typed
 	b.WriteString("\n")
-	b.WriteString(ui.PendingStyle.Render("Press q to quit"))
+	if m.gate != nil {
+		b.WriteString(m.gatePrompt() + "\n")
+	}
+	if m.watcher != nil {
+		b.WriteString(m.watchStatus() + "\n")
+	}
+	if m.reviewing {
+		b.WriteString(ui.PromptStyle.Render(fmt.Sprintf("Review: %d of %d steps will run. Press %s to start.",
+			m.selectedCount(), len(m.commands), m.keys.Continue.Help().Key)) + "\n")
+	}
+	switch {
+	case m.editing != editNone:
+		b.WriteString(m.input.View())
+	case m.notice != "":
+		b.WriteString(ui.PromptStyle.Render(m.notice))
+	default:
+		b.WriteString(m.helpBar())
+	}
 
 	// Show log file path if available
 	if logPath := m.LoggerPath(); logPath != "" {
 		b.WriteString("\n")
 		b.WriteString(ui.PendingStyle.Render(fmt.Sprintf("Debug log: %s", logPath)))
 	}
+	if m.replay != "" {
+		b.WriteString("\n")
+		b.WriteString(ui.PendingStyle.Render(fmt.Sprintf("Viewing log: %s", m.replay)))
+	}
 
 	return b.String()
 }
 
-// renderFailedCommandOutput shows the output of the failed command
-func (m Model) renderFailedCommandOutput() string {
-	cmd := m.failedCommand
+// renderRows renders the command rows that fit, with scroll indicators
+func (m Model) renderRows(width int) string {
+	var b strings.Builder
+
+	rows := m.rows()
+	first, last, clipped := m.visibleRows()
+	if clipped {
+		b.WriteString(m.scrollIndicator("↑", first) + "\n")
+	}
+
+	for i := first; i < last; i++ {
+		row := rows[i]
+		if row.isHeader() {
+			g := m.groups[row.group]
+			line := ui.FormatGroupLine(g, m.collapsed[row.group], i == m.selected, m.spinner.View(), width)
+			b.WriteString(line + "\n")
+			continue
+		}
+
+		cmd := m.commands[row.command]
+		// Check if this is the currently running command
+		isRunning := (row.command == m.executing)
+		spinnerView := ""
+		if isRunning {
+			spinnerView = m.spinner.View()
+		}
+
+		// Commands in a group are indented under its header
+		if row.group >= 0 {
+			line := ui.FormatCommandLineWithSpinner(cmd, i == m.selected, spinnerView, width-2)
+			b.WriteString("  " + line + "\n")
+			continue
+		}
+		line := ui.FormatCommandLineWithSpinner(cmd, i == m.selected, spinnerView, width)
+		b.WriteString(line + "\n")
+	}
+
+	if clipped {
+		b.WriteString(m.scrollIndicator("↓", len(rows)-last) + "\n")
+	}
+
+	return b.String()
+}
+
+// renderPreview renders the selected command's output for the split list
+func (m Model) renderPreview() string {
+	cmd := m.viewer.Command()
+	if cmd == nil {
+		return ""
+	}
+
+	header := m.previewHeader(cmd)
+	if len(cmd.Output) == 0 && !cmd.Active() {
+		return header + ui.PendingStyle.Render("(No output captured)")
+	}
+	return header + m.viewer.View()
+}
+
+// previewHeader renders the lines above the output preview: the step and its
+// status, and the command of a named step
+func (m Model) previewHeader(cmd *executor.Command) string {
+	width := m.layout().RightWidth() - 2
+	title := ui.TitleStyle.Render(cmd.Label()) + ui.PendingStyle.Render(fmt.Sprintf("  (%s)", cmd.Status))
+	return ansi.Truncate(title, width, "…") + "\n" + commandLine(cmd, width)
+}
+
+// commandLine renders the command of a named step, whose title shows only the
+// name, on a line of its own. Unnamed steps get nothing, as their title is
+// the command.
+func commandLine(cmd *executor.Command, width int) string {
+	if cmd.Name == "" || cmd.Raw == "" {
+		return ""
+	}
+	line := ui.PendingStyle.Render("Command: " + cmd.CommandLine())
+	if width > 0 {
+		line = ansi.Truncate(line, width, "…")
+	}
+	return line + "\n"
+}
+
+// visibleRows returns the range of commands drawn in the list and whether
+// the list is clipped, in which case scroll indicators surround it
+func (m Model) visibleRows() (first, last int, clipped bool) {
+	total := len(m.rows())
+	first, last = 0, total
+	clipped = m.listRows() < total
+	if clipped {
+		first = min(m.listOffset, total-m.listRows())
+		last = first + m.listRows()
+	}
+	return first, last, clipped
+}
+
+// rowAt returns the index of the list row drawn on screen line y
+func (m Model) rowAt(y int) (int, bool) {
+	first, last, clipped := m.visibleRows()
+	if clipped {
+		// Skip the "↑ more" indicator
+		y--
+	}
+	if y < 0 || first+y >= last {
+		return 0, false
+	}
+	return first + y, true
+}
+
+// splitView reports whether the list is drawn beside an output preview
+func (m Model) splitView() bool {
+	return m.fullscreen && m.width >= minSplitWidth
+}
+
+// layout returns the two-panel layout used by the split list
+func (m Model) layout() ui.Layout {
+	return ui.NewLayout(m.width, m.listHeight())
+}
+
+// listFooter returns the number of lines drawn below the command rows
+func (m Model) listFooter() int {
+	// Blank line and help bar, plus the log path if there is one
+	lines := 2
+	if m.reviewing || m.gate != nil {
+		lines++
+	}
+	if m.watcher != nil {
+		lines++
+	}
+	if m.LoggerPath() != "" || m.replay != "" {
+		lines++
+	}
+	return lines
+}
+
+// listHeight returns the number of lines available for the command list
+func (m Model) listHeight() int {
+	// Leave a spare line so the inline renderer never scrolls the terminal
+	return m.height - m.listFooter() - 1
+}
+
+// listRows returns how many list rows fit on screen
+func (m Model) listRows() int {
+	total := len(m.rows())
+	if m.height <= 0 {
+		return total
+	}
+
+	available := m.listHeight()
+	if total <= available {
+		return total
+	}
+
+	// Two lines are taken by the scroll indicators
+	return max(available-2, 1)
+}
+
+// scrollList adjusts the list offset so the selected row is visible
+func (m *Model) scrollList() {
+	rows := m.listRows()
+	if m.selected < m.listOffset {
+		m.listOffset = m.selected
+	} else if m.selected >= m.listOffset+rows {
+		m.listOffset = m.selected - rows + 1
+	}
+	m.listOffset = max(min(m.listOffset, len(m.rows())-rows), 0)
+}
+
+// scrollIndicator shows how many commands are hidden above or below the list
+func (m Model) scrollIndicator(arrow string, hidden int) string {
+	if hidden <= 0 {
+		return ""
+	}
+	return ui.PendingStyle.Render(fmt.Sprintf("  %s %d more", arrow, hidden))
+}
+
+// renderOutput shows the output viewer between its header and footer
+func (m Model) renderOutput() string {
+	cmd := m.viewer.Command()
 
 	var b strings.Builder
+	b.WriteString(m.outputHeader(cmd))
+
+	if len(cmd.Output) == 0 && !cmd.Active() {
+		b.WriteString("(No output captured)\n")
+	} else {
+		b.WriteString(m.viewer.View() + "\n")
+	}
+
+	b.WriteString(m.outputFooter())
+	return b.String()
+}
+
+// outputHeader renders the lines above the output viewer. For failed commands
+// this includes the failure details.
+func (m Model) outputHeader(cmd *executor.Command) string {
+	var b strings.Builder
+
+	if m.mode != modeFailure {
+		b.WriteString(ui.TitleStyle.Render(fmt.Sprintf("Output: %s", cmd.Label())))
+		b.WriteString(ui.PendingStyle.Render(fmt.Sprintf("  (%s)", cmd.Status)) + "\n")
+		b.WriteString(commandLine(cmd, m.width))
+		b.WriteString(strings.Repeat("─", 60) + "\n")
+		return b.String()
+	}
 
 	b.WriteString(ui.ErrorStyle.Render("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━") + "\n")
 	b.WriteString(ui.ErrorStyle.Render("Command Failed!") + "\n")
 	b.WriteString(ui.ErrorStyle.Render("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━") + "\n\n")
 
-	b.WriteString(fmt.Sprintf("Command: %s\n", ui.ErrorStyle.Render(cmd.Raw)))
+	if cmd.Group != "" {
+		b.WriteString(fmt.Sprintf("Group: %s\n", ui.ErrorStyle.Render(cmd.Group)))
+	}
+	if cmd.Name != "" {
+		b.WriteString(fmt.Sprintf("Step: %s\n", ui.ErrorStyle.Render(cmd.Label())))
+	}
+	b.WriteString(fmt.Sprintf("Command: %s\n", ui.ErrorStyle.Render(cmd.Secrets.Mask(cmd.Raw))))
 	b.WriteString(fmt.Sprintf("Exit Code: %s\n\n", ui.ErrorStyle.Render(fmt.Sprintf("%d", cmd.ExitCode))))
 
 	if cmd.Error != nil {
-		b.WriteString(fmt.Sprintf("Error: %s\n\n", ui.ErrorStyle.Render(cmd.Error.Error())))
+		b.WriteString(fmt.Sprintf("Error: %s\n\n", ui.ErrorStyle.Render(cmd.Secrets.Mask(cmd.Error.Error()))))
 	}
 
-	// Show command output
-	if len(cmd.Output) > 0 {
-		b.WriteString(ui.TitleStyle.Render("Output:") + "\n")
-		b.WriteString(strings.Repeat("─", 60) + "\n")
-
-		// Show all output (or last N lines if too long)
-		maxLines := m.height - 15 // Reserve space for header
-		if maxLines < 20 {
-			maxLines = 20
-		}
+	b.WriteString(ui.TitleStyle.Render("Output:") + "\n")
+	b.WriteString(strings.Repeat("─", 60) + "\n")
 
-		startIdx := 0
-		if len(cmd.Output) > maxLines {
-			startIdx = len(cmd.Output) - maxLines
-		}
+	return b.String()
+}
 
-		for i := startIdx; i < len(cmd.Output); i++ {
-			b.WriteString(cmd.Output[i] + "\n")
-		}
+// outputFooter renders the lines below the output viewer
+func (m Model) outputFooter() string {
+	var b strings.Builder
 
-		// Show indicator if there's more output
-		if startIdx > 0 {
-			b.WriteString(ui.PendingStyle.Render(fmt.Sprintf("\n... (%d more lines above)", startIdx)))
-		}
+	if m.mode == modeFailure {
+		b.WriteString(ui.ErrorStyle.Render("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━") + "\n")
+	}
+	if m.gate != nil {
+		b.WriteString(m.gateHint() + "\n")
+	}
+	if m.attached != nil {
+		b.WriteString(m.attachedLine() + "\n")
+	}
+	if m.notice != "" {
+		b.WriteString(ui.PromptStyle.Render(m.notice))
 	} else {
-		b.WriteString("(No output captured)\n")
+		b.WriteString(m.helpBar())
 	}
 
-	b.WriteString("\n" + ui.ErrorStyle.Render("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━") + "\n")
-
 	// Show log file path if available
 	if logPath := m.LoggerPath(); logPath != "" {
 		b.WriteString("\n")
 		b.WriteString(ui.PendingStyle.Render(fmt.Sprintf("Full debug log: %s", logPath)))
 	}
+	if m.replay != "" {
+		b.WriteString("\n")
+		b.WriteString(ui.PendingStyle.Render(fmt.Sprintf("Viewing log: %s", m.replay)))
+	}
 
 	return b.String()
 }
+
+// helpBar renders the key hints for the current mode
+func (m Model) helpBar() string {
+	var bindings []key.Binding
+	switch m.mode {
+	case modeOutput:
+		if m.attached != nil {
+			bindings = m.keys.AttachedHelp()
+			break
+		}
+		cmd := m.viewer.Command()
+		bindings = m.keys.OutputHelp(cmd != nil && cmd.Active())
+	case modeFailure:
+		bindings = m.keys.FailureHelp()
+	default:
+		if m.reviewing {
+			bindings = m.keys.ReviewHelp()
+			break
+		}
+		if m.gate != nil {
+			bindings = m.keys.ApprovalHelp()
+			break
+		}
+		running := m.runningCommand()
+		bindings = m.keys.ListHelp(len(m.groups) > 0, running != nil, running != nil && running.Status == executor.StatusPaused)
+	}
+	return m.help.ShortHelpView(bindings)
+}
+
+// renderHelp renders the full help overlay listing every binding
+func (m Model) renderHelp() string {
+	var b strings.Builder
+
+	b.WriteString(ui.TitleStyle.Render("Key Bindings") + "\n\n")
+	b.WriteString(m.help.FullHelpView(m.keys.FullHelp()) + "\n\n")
+	b.WriteString(ui.PendingStyle.Render(m.helpCloseHint()))
+
+	return ui.BorderStyle.Padding(0, 1).Render(b.String())
+}
+
+// helpCloseHint names the keys that close the help overlay, as configured
+func (m Model) helpCloseHint() string {
+	var keys []string
+	for _, k := range []string{m.keys.Help.Help().Key, m.keys.Back.Help().Key} {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Press %s to close", strings.Join(keys, " or "))
+}
+
+// viewerHeight returns the number of lines left for the output viewer once
+// the header and footer for the given command are drawn
+func (m Model) viewerHeight(cmd *executor.Command) int {
+	used := lineCount(m.outputHeader(cmd)) + lineCount(m.outputFooter())
+
+	// Leave a spare line so the inline renderer never scrolls the terminal
+	height := m.height - used - 1
+	if height < 5 {
+		height = 5
+	}
+	return height
+}
+
+// lineCount returns the number of terminal lines a rendered block occupies
+func lineCount(s string) int {
+	if s == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(s, "\n"), "\n") + 1
+}
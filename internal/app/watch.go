@@ -0,0 +1,115 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/ui"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// watchMsg reports a change to a watched file
+type watchMsg struct {
+	file string
+}
+
+// waitForChange waits in the background for the next change to the watched files
+func (m Model) waitForChange() tea.Cmd {
+	w := m.watcher
+	if w == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return watchMsg{file: w.Wait()}
+	}
+}
+
+// runDone runs the run's hooks and ends the program once every command has
+// finished. A stopped run exits as soon as its cleanup steps are done. After
+// a failure the program stays open so the error can be read, and in watch
+// mode the results stay on screen until the next change starts another run.
+func (m *Model) runDone() tea.Cmd {
+	if m.stopping {
+		return tea.Quit
+	}
+	// The run's hooks finish before the program exits
+	hooks := m.runHooks()
+	if m.watcher != nil || m.failedCommand != nil {
+		return hooks
+	}
+	return tea.Sequence(hooks, tea.Quit)
+}
+
+// changed reruns the commands after a watched file changed. A running command
+// is cancelled first, and the new run starts once it has stopped.
+func (m *Model) changed(file string) tea.Cmd {
+	// Changes during the review are picked up once the run starts
+	if m.reviewing || m.stopping || m.rerunTrigger != "" {
+		return nil
+	}
+
+	if cmd := m.runningCommand(); cmd != nil {
+		m.rerunTrigger = file
+		cmd.Cancel()
+		return nil
+	}
+	return m.rerun(file)
+}
+
+// rerun starts the command list again from the first step. Steps turned off
+// in the review stay off.
+func (m *Model) rerun(trigger string) tea.Cmd {
+	if m.attached != nil {
+		m.detach()
+	}
+	m.stopInput()
+
+	for i, cmd := range m.commands {
+		fresh := cmd.Rerun()
+		if cmd.SkipReason == skipNotSelected {
+			setSelected(fresh, false)
+		}
+		m.commands[i] = fresh
+	}
+	m.groups = executor.Groups(m.commands)
+	if len(m.collapsed) != len(m.groups) {
+		m.collapsed = make([]bool, len(m.groups))
+	}
+
+	m.run++
+	m.trigger = trigger
+	m.executing = -1
+	m.failedCommand = nil
+	m.gate = nil
+	m.declined = nil
+	m.workingDir = m.startDir
+	m.manualSelect = false
+	m.mode = modeList
+	m.notice = ""
+
+	if m.logger != nil {
+		m.logger.LogWatchRun(m.run, trigger)
+		m.logger.LogCommands(m.commands)
+		for _, cmd := range m.commands {
+			if cmd.Status == executor.StatusSkipped {
+				m.logger.LogCommandSkipped(cmd)
+			}
+		}
+	}
+
+	return m.executeNext()
+}
+
+// watchStatus renders the watch mode line: the patterns, the run counter and
+// the change that started the current run
+func (m Model) watchStatus() string {
+	status := fmt.Sprintf("Watching %s · run %d", strings.Join(m.watcher.Globs(), ", "), m.run)
+	if m.trigger != "" {
+		status += fmt.Sprintf(" · %s changed", m.trigger)
+	}
+	if m.AllCommandsDone() {
+		status += " · waiting for changes"
+	}
+	return ui.PendingStyle.Render(status)
+}
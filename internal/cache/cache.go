@@ -0,0 +1,277 @@
+// Package cache stores the results of successful steps, keyed by a hash of
+// everything the result depends on, so unchanged steps can be skipped
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/glob"
+)
+
+// entryFile is the metadata file of a cache entry
+const entryFile = "entry.json"
+
+// filesDir holds the saved outputs of a cache entry
+const filesDir = "files"
+
+// Cached output can contain anything a step printed, so the cache is private
+// to the user like the debug logs
+const (
+	dirPerm  = 0o700
+	filePerm = 0o600
+)
+
+// format is hashed into every key and changes when entries gain information
+// older ones lack, such as the directory a step ends in
+const format = "2"
+
+// volatileEnv are environment variables that differ between terminals and
+// shells without affecting what a command does, so they are not hashed
+var volatileEnv = map[string]bool{
+	"_": true, "PWD": true, "OLDPWD": true, "SHLVL": true, "WINDOWID": true, "TMUX": true,
+	"SSH_AUTH_SOCK": true, "SSH_CLIENT": true, "SSH_CONNECTION": true, "WT_SESSION": true,
+	"COLUMNS": true, "LINES": true,
+}
+
+// volatileEnvSuffixes match per-terminal identifiers such as ITERM_SESSION_ID
+// and KITTY_WINDOW_ID
+var volatileEnvSuffixes = []string{"_SESSION_ID", "_WINDOW_ID", "_PANE", "_TTY"}
+
+// volatile reports whether an environment variable is left out of the key
+func volatile(name string) bool {
+	if volatileEnv[name] {
+		return true
+	}
+	for _, suffix := range volatileEnvSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cache is a directory of cached step results
+type Cache struct {
+	dir string
+}
+
+// Entry is a cached successful result
+type Entry struct {
+	Command  string    `json:"command"`
+	Output   []string  `json:"output"`
+	Duration int64     `json:"duration_ms"`   // How long the step took when it ran
+	Files    []string  `json:"files"`         // Saved outputs, relative to the working directory
+	Dir      string    `json:"dir,omitempty"` // Working directory the step ended in, for later steps
+	Created  time.Time `json:"created"`
+
+	dir string
+}
+
+// DefaultDir returns the cache directory in the user's cache directory
+// (usually ~/.cache/lazycommands/steps)
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the cache directory: %w", err)
+	}
+	return filepath.Join(dir, "lazycommands", "steps"), nil
+}
+
+// New returns a cache stored in dir, which is created when the first result is stored
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Dir returns the directory the cache is stored in
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Key hashes what a step's result depends on: the command, the shell and
+// working directory it runs in, the environment, its declared inputs and
+// outputs, and the contents of every input file
+func Key(cmd *executor.Command, workingDir, shell string) (string, error) {
+	h := sha256.New()
+	field := func(name, value string) {
+		fmt.Fprintf(h, "%s %d:%s\n", name, len(value), value)
+	}
+
+	field("format", format)
+	field("command", cmd.Raw)
+	field("shell", shell)
+	field("dir", workingDir)
+	for _, g := range cmd.Inputs {
+		field("input", g)
+	}
+	for _, g := range cmd.Outputs {
+		field("output", g)
+	}
+
+	env := os.Environ()
+	sort.Strings(env)
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if !volatile(name) {
+			field("env", kv)
+		}
+	}
+
+	patterns, err := glob.CompileAll(cmd.Inputs)
+	if err != nil {
+		return "", err
+	}
+	for _, rel := range glob.Files(workingDir, patterns) {
+		field("file", rel)
+		if err := hashFile(h, filepath.Join(workingDir, rel)); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile adds a file's contents to h
+func hashFile(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	h.Write(sum.Sum(nil))
+	return nil
+}
+
+// Lookup returns the cached result for key, if there is one
+func (c *Cache) Lookup(key string) (*Entry, bool) {
+	dir := c.entryDir(key)
+	data, err := os.ReadFile(filepath.Join(dir, entryFile))
+	if err != nil {
+		return nil, false
+	}
+
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false
+	}
+	e.dir = dir
+	return &e, true
+}
+
+// Restore copies the entry's saved outputs back into the working directory.
+// It fails if the directory the step ended in is gone, since later steps
+// would run there.
+func (e *Entry) Restore(workingDir string) error {
+	if e.Dir != "" {
+		if info, err := os.Stat(e.Dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("the step's working directory %s no longer exists", e.Dir)
+		}
+	}
+	for _, rel := range e.Files {
+		if err := copyFile(filepath.Join(e.dir, filesDir, rel), filepath.Join(workingDir, rel), 0o755); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+// Store saves the result of a command that succeeded under key, along with
+// the files matching its declared outputs and the directory it ended in
+func (c *Cache) Store(key string, cmd *executor.Command, workingDir, newDir string) error {
+	patterns, err := glob.CompileAll(cmd.Outputs)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, dirPerm); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Build the entry beside the cache and move it into place, so a lookup
+	// never sees a partly written entry
+	tmp, err := os.MkdirTemp(c.dir, "tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	e := Entry{
+		Command:  cmd.Secrets.Mask(cmd.Raw),
+		Output:   cmd.Output,
+		Duration: cmd.Duration().Milliseconds(),
+		Files:    glob.Files(workingDir, patterns),
+		Dir:      newDir,
+		Created:  time.Now(),
+	}
+	for _, rel := range e.Files {
+		if err := copyFile(filepath.Join(workingDir, rel), filepath.Join(tmp, filesDir, rel), dirPerm); err != nil {
+			return fmt.Errorf("failed to save output %s: %w", rel, err)
+		}
+	}
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, entryFile), data, filePerm); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	dir := c.entryDir(key)
+	if err := os.MkdirAll(filepath.Dir(dir), dirPerm); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	os.RemoveAll(dir)
+	if err := os.Rename(tmp, dir); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return nil
+}
+
+// entryDir returns where the entry for key is stored
+func (c *Cache) entryDir(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// copyFile copies a file, creating the destination's directories with dirMode
+// and keeping the file's permissions
+func copyFile(src, dst string, dirMode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), dirMode); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,50 @@
+package clipboard
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// Copy places text on the system clipboard using the OSC 52 terminal escape
+// sequence. This works over SSH as long as the local terminal supports OSC 52.
+func Copy(text string) error {
+	out, closeOut, err := terminal()
+	if err != nil {
+		return err
+	}
+	defer closeOut()
+
+	seq := osc52.New(text)
+
+	// Terminal multiplexers need the sequence wrapped to pass it through
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case os.Getenv("STY") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+
+	if _, err := seq.WriteTo(out); err != nil {
+		return fmt.Errorf("failed to write to terminal: %w", err)
+	}
+	return nil
+}
+
+// terminal returns a writer connected to the controlling terminal and a
+// function to release it. Stdout is owned by the TUI renderer, so the sequence
+// goes to stderr when it is a terminal and to /dev/tty otherwise.
+func terminal() (io.Writer, func(), error) {
+	if info, err := os.Stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return os.Stderr, func() {}, nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no terminal available for clipboard: %w", err)
+	}
+	return tty, func() { tty.Close() }, nil
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/alameenkhader/lazycommands/internal/keys"
+	"github.com/alameenkhader/lazycommands/internal/log"
+	"github.com/alameenkhader/lazycommands/internal/secrets"
+	"github.com/alameenkhader/lazycommands/internal/ui"
+)
+
+// Config holds user settings loaded from the config file
+type Config struct {
+	Shell     string              `json:"shell"`      // Shell used to run commands (defaults to $SHELL)
+	LogFormat log.Format          `json:"log_format"` // Debug log format: text or json
+	Theme     string              `json:"theme"`      // Color theme name, or auto
+	Icons     string              `json:"icons"`      // Status icon set: emoji or ascii
+	Keys      map[string][]string `json:"keys"`       // Key bindings by name
+
+	LogDir        string        `json:"log_dir"`         // Directory for debug logs (defaults to the system temp directory)
+	LogPerProject bool          `json:"log_per_project"` // Keep each project's logs in their own subdirectory
+	LogRetention  log.Retention `json:"log_retention"`   // Which logs of earlier runs are kept
+	LogCompress   bool          `json:"log_compress"`    // Gzip the logs of earlier runs
+
+	// SecretPatterns are regular expressions whose matches are masked in
+	// output and logs
+	SecretPatterns []string `json:"secret_patterns"`
+}
+
+// Default returns the built-in settings
+func Default() Config {
+	km := keys.DefaultKeyMap()
+	bindings := make(map[string][]string)
+	for _, name := range km.Names() {
+		bindings[name], _ = km.Keys(name)
+	}
+
+	return Config{
+		LogFormat: log.FormatText,
+		Theme:     "auto",
+		Icons:     "emoji",
+		Keys:      bindings,
+
+		SecretPatterns: []string{},
+	}
+}
+
+// DefaultPath returns the config file location in the user's config directory
+// ($XDG_CONFIG_HOME/lazycommands/config.json on Linux)
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lazycommands", "config.json")
+}
+
+// Load reads the config file at path and merges it over the defaults. A
+// missing file is not an error; the defaults are returned unchanged.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := cfg.merge(data); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// merge applies the settings in data, validating each key
+func (c *Config) merge(data []byte) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	for _, name := range sortedKeys(raw) {
+		value := raw[name]
+		switch name {
+		case "shell":
+			if err := json.Unmarshal(value, &c.Shell); err != nil {
+				return fmt.Errorf("shell: must be a string")
+			}
+
+		case "log_format":
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				return fmt.Errorf("log_format: must be a string")
+			}
+			format, err := log.ParseFormat(s)
+			if err != nil {
+				return fmt.Errorf("log_format: %w", err)
+			}
+			c.LogFormat = format
+
+		case "theme":
+			if err := json.Unmarshal(value, &c.Theme); err != nil {
+				return fmt.Errorf("theme: must be a string")
+			}
+			if err := ValidateTheme(c.Theme); err != nil {
+				return fmt.Errorf("theme: %w", err)
+			}
+
+		case "icons":
+			if err := json.Unmarshal(value, &c.Icons); err != nil {
+				return fmt.Errorf("icons: must be a string")
+			}
+			if err := ValidateIcons(c.Icons); err != nil {
+				return fmt.Errorf("icons: %w", err)
+			}
+
+		case "keys":
+			if err := c.mergeKeys(value); err != nil {
+				return err
+			}
+
+		case "log_dir":
+			if err := json.Unmarshal(value, &c.LogDir); err != nil {
+				return fmt.Errorf("log_dir: must be a string")
+			}
+
+		case "log_per_project":
+			if err := json.Unmarshal(value, &c.LogPerProject); err != nil {
+				return fmt.Errorf("log_per_project: must be true or false")
+			}
+
+		case "log_retention":
+			if err := json.Unmarshal(value, &c.LogRetention); err != nil {
+				return fmt.Errorf("log_retention: %w", err)
+			}
+
+		case "log_compress":
+			if err := json.Unmarshal(value, &c.LogCompress); err != nil {
+				return fmt.Errorf("log_compress: must be true or false")
+			}
+
+		case "secret_patterns":
+			if err := json.Unmarshal(value, &c.SecretPatterns); err != nil {
+				return fmt.Errorf("secret_patterns: must be a list of regular expressions")
+			}
+			for i, pattern := range c.SecretPatterns {
+				if err := secrets.New().AddPattern(pattern); err != nil {
+					return fmt.Errorf("secret_patterns[%d]: %w", i, err)
+				}
+			}
+
+		default:
+			return fmt.Errorf("unknown setting %q (valid: icons, keys, log_compress, log_dir, log_format, log_per_project, log_retention, secret_patterns, shell, theme)", name)
+		}
+	}
+
+	return nil
+}
+
+// mergeKeys applies key binding overrides. Each binding may be a single key
+// or a list of keys.
+func (c *Config) mergeKeys(data json.RawMessage) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("keys: must be an object mapping binding names to keys")
+	}
+
+	km := keys.DefaultKeyMap()
+	for _, name := range sortedKeys(raw) {
+		if _, ok := km.Keys(name); !ok {
+			return fmt.Errorf("keys.%s: unknown binding (valid: %s)", name, strings.Join(km.Names(), ", "))
+		}
+
+		var list []string
+		var single string
+		if err := json.Unmarshal(raw[name], &single); err == nil {
+			list = []string{single}
+		} else if err := json.Unmarshal(raw[name], &list); err != nil {
+			return fmt.Errorf("keys.%s: must be a key or a list of keys", name)
+		}
+
+		if err := km.Rebind(name, list); err != nil {
+			return fmt.Errorf("keys.%s: %w", name, err)
+		}
+		c.Keys[name] = list
+	}
+
+	if conflicts := km.Conflicts(); len(conflicts) > 0 {
+		return fmt.Errorf("keys: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// ValidateTheme checks that name is a known theme
+func ValidateTheme(name string) error {
+	for _, valid := range ui.ThemeNames() {
+		if name == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown theme %q (valid: %s)", name, strings.Join(ui.ThemeNames(), ", "))
+}
+
+// ValidateIcons checks that name is a known icon set
+func ValidateIcons(name string) error {
+	for _, valid := range ui.IconSetNames() {
+		if name == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown icon set %q (valid: %s)", name, strings.Join(ui.IconSetNames(), ", "))
+}
+
+// KeyMap builds the key bindings described by the config
+func (c Config) KeyMap() keys.KeyMap {
+	km := keys.DefaultKeyMap()
+	defaults := Default()
+	for name, list := range c.Keys {
+		// Only rebind changed keys so the default help labels (↑/k) are kept
+		if strings.Join(list, "\x00") == strings.Join(defaults.Keys[name], "\x00") {
+			continue
+		}
+		km.Rebind(name, list)
+	}
+	return km
+}
+
+// String renders the config as indented JSON
+func (c Config) String() string {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// sortedKeys returns the keys of m in order so errors are reported consistently
+func sortedKeys(m map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
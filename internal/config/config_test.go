@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string // Expected error, empty if the config loads
+	}{
+		{"rebind", `{"keys": {"quit": "Q", "stop": ["N", "ctrl+n"]}}`, ""},
+		{"shared across screens", `{"keys": {"detach": "ctrl+]", "copy_output": "a"}}`, ""},
+		{"unknown binding", `{"keys": {"launch": "l"}}`, "keys.launch: unknown binding"},
+		{"conflict with a default", `{"keys": {"next_match": "/"}}`, `keys: "/" is bound to both search and next_match on the output screen`},
+		{"conflict between overrides", `{"keys": {"insert": "z", "fold": "z"}}`, `keys: "z" is bound to both fold and insert on the command list screen`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Load(path)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Load: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Load error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
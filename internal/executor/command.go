@@ -2,11 +2,17 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/hooks"
+	"github.com/alameenkhader/lazycommands/internal/secrets"
 )
 
 // CommandStatus represents the execution state of a command
@@ -18,6 +24,9 @@ const (
 	StatusCompleted
 	StatusFailed
 	StatusSkipped
+	StatusWaiting // Approval gate waiting for an answer
+	StatusPaused  // Running command suspended by the user
+	StatusCached  // Skipped because a cached successful result matched
 )
 
 // String returns a string representation of the command status
@@ -33,25 +42,68 @@ func (s CommandStatus) String() string {
 		return "Failed"
 	case StatusSkipped:
 		return "Skipped"
+	case StatusWaiting:
+		return "Waiting"
+	case StatusPaused:
+		return "Paused"
+	case StatusCached:
+		return "Cached"
 	default:
 		return "Unknown"
 	}
 }
 
+// ParseStatus reads a status written by String
+func ParseStatus(s string) (CommandStatus, bool) {
+	for status := StatusPending; status <= StatusCached; status++ {
+		if status.String() == s {
+			return status, true
+		}
+	}
+	return StatusPending, false
+}
+
+// Succeeded reports whether the status is a successful result, either run
+// or restored from the cache
+func (s CommandStatus) Succeeded() bool {
+	return s == StatusCompleted || s == StatusCached
+}
+
 // Command wraps a shell command with its execution state
 type Command struct {
 	ID          int
-	Raw         string        // Original command string
-	Status      CommandStatus // Current execution status
-	Output      []string      // Captured stdout/stderr lines
-	ExitCode    int           // Exit code of the command
-	StartTime   time.Time     // When the command started
-	EndTime     time.Time     // When the command finished
-	Error       error         // Error if the command failed
-	WorkingDir  string        // Working directory for this command
-	IsCdCommand bool          // True if this is a cd command
+	StepID      string          // Workflow id that conditions use to refer to the step
+	Name        string          // Optional display name for the step
+	Group       string          // Optional group the step belongs to
+	Raw         string          // Original command string
+	Pause       string          // Approval prompt; the step waits for an answer instead of running Raw
+	Inputs      []string        // Globs of the files the result depends on; enables caching
+	Outputs     []string        // Globs of the files the step produces, restored from the cache
+	If          string          // Condition that must hold for the step to run
+	Always      bool            // Cleanup step: runs even after a failure or when the run is stopped
+	Hooks       hooks.Set       // Run when the step succeeds or fails
+	Secret      bool            // Every output line is a secret, masked here and in later output
+	Secrets     *secrets.Masker // Secrets masked in the output, label and logs
+	Status      CommandStatus   // Current execution status
+	Output      []string        // Captured stdout/stderr lines
+	ExitCode    int             // Exit code of the command
+	StartTime   time.Time       // When the command started
+	EndTime     time.Time       // When the command finished
+	Error       error           // Error if the command failed
+	SkipReason  string          // Why the command was skipped
+	WorkingDir  string          // Working directory for this command
+	IsCdCommand bool            // True if this is a cd command
+	AcceptInput bool            // Connect stdin to a pipe so input can be sent while it runs; otherwise it reads end-of-file
 	ctx         context.Context
 	cancel      context.CancelFunc
+
+	pid         int           // Process group leader while running
+	pausedAt    time.Time     // When the current pause began
+	pausedTotal time.Duration // Time spent paused, excluded from Duration
+
+	ioMu    sync.Mutex
+	stdin   io.WriteCloser // Pipe to stdin while running, if AcceptInput is set
+	partial string         // Output after the last newline, such as a prompt
 }
 
 const maxOutputLines = 1000
@@ -69,6 +121,49 @@ func NewCommand(id int, raw string) *Command {
 	}
 }
 
+// Rerun returns a pending copy of the step for running it again
+func (c *Command) Rerun() *Command {
+	fresh := NewCommand(c.ID, c.Raw)
+	fresh.StepID = c.StepID
+	fresh.Name = c.Name
+	fresh.If = c.If
+	fresh.Always = c.Always
+	fresh.Hooks = c.Hooks
+	fresh.Secret = c.Secret
+	fresh.Secrets = c.Secrets
+	fresh.Group = c.Group
+	fresh.Pause = c.Pause
+	fresh.Inputs = c.Inputs
+	fresh.Outputs = c.Outputs
+	fresh.AcceptInput = c.AcceptInput
+	return fresh
+}
+
+// Label returns the step's display name, falling back to the command text
+// collapsed onto a single line
+func (c *Command) Label() string {
+	if c.Name != "" {
+		return c.Secrets.Mask(c.Name)
+	}
+	if c.Pause != "" {
+		return c.Secrets.Mask(c.Pause)
+	}
+	return c.CommandLine()
+}
+
+// CommandLine returns the command text collapsed onto a single line, with its
+// secrets masked
+func (c *Command) CommandLine() string {
+	return c.Secrets.Mask(singleLine(c.Raw))
+}
+
+// singleLine collapses a multi-line command (continuations, blocks, heredocs)
+// into one line suitable for lists and summaries
+func singleLine(raw string) string {
+	raw = strings.ReplaceAll(raw, "\\\n", " ")
+	return strings.Join(strings.Fields(raw), " ")
+}
+
 // AppendOutput adds a line to the command's output, maintaining a sliding window
 // to prevent memory issues with very long outputs
 func (c *Command) AppendOutput(line string) {
@@ -81,20 +176,79 @@ func (c *Command) AppendOutput(line string) {
 
 // Cancel cancels the command's context
 func (c *Command) Cancel() {
+	// Let a paused command run again so its processes can exit
+	if c.Status == StatusPaused {
+		c.Resume()
+	}
 	if c.cancel != nil {
 		c.cancel()
 	}
 }
 
-// Duration returns the duration of the command execution
+// Duration returns the duration of the command execution, not counting time
+// spent paused
 func (c *Command) Duration() time.Duration {
 	if c.StartTime.IsZero() {
 		return 0
 	}
-	if c.EndTime.IsZero() {
-		return time.Since(c.StartTime)
+
+	end := c.EndTime
+	switch {
+	case !c.pausedAt.IsZero():
+		end = c.pausedAt
+	case end.IsZero():
+		end = time.Now()
+	}
+	return end.Sub(c.StartTime) - c.pausedTotal
+}
+
+// Active reports whether the command has started and not finished, including
+// while it is paused
+func (c *Command) Active() bool {
+	return c.Status == StatusRunning || c.Status == StatusPaused
+}
+
+// Suspend pauses the running command and every process it started
+func (c *Command) Suspend() error {
+	if c.Status != StatusRunning || c.pid == 0 {
+		return errors.New("the command is not running")
+	}
+	if err := stopProcessGroup(c.pid); err != nil {
+		return fmt.Errorf("failed to pause: %w", err)
+	}
+	c.Status = StatusPaused
+	c.pausedAt = time.Now()
+	return nil
+}
+
+// Resume continues a command paused by Suspend
+func (c *Command) Resume() error {
+	if c.Status != StatusPaused {
+		return errors.New("the command is not paused")
+	}
+	if err := continueProcessGroup(c.pid); err != nil {
+		return fmt.Errorf("failed to resume: %w", err)
+	}
+	c.pausedTotal += time.Since(c.pausedAt)
+	c.pausedAt = time.Time{}
+	c.Status = StatusRunning
+	return nil
+}
+
+// StoppedOnTerminal reports whether a process of the running command has
+// stopped, which happens when it reads the terminal itself (/dev/tty, as ssh
+// and sudo prompts do): commands run in the background of their own process
+// group. A command paused with Suspend does not count.
+func (c *Command) StoppedOnTerminal() bool {
+	return c.Status == StatusRunning && c.pid != 0 && groupStopped(c.pid)
+}
+
+// PausedFor returns how long the command has been paused in total
+func (c *Command) PausedFor() time.Duration {
+	if !c.pausedAt.IsZero() {
+		return c.pausedTotal + time.Since(c.pausedAt)
 	}
-	return c.EndTime.Sub(c.StartTime)
+	return c.pausedTotal
 }
 
 // ParseCdCommand checks if a command is a cd command and extracts the target directory.
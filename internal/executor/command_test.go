@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/alameenkhader/lazycommands/internal/hooks"
+	"github.com/alameenkhader/lazycommands/internal/secrets"
+)
+
+func TestRerun(t *testing.T) {
+	c := NewCommand(3, "make test")
+	c.StepID = "test"
+	c.Name = "Tests"
+	c.Group = "Check"
+	c.Pause = "Run the tests?"
+	c.Inputs = []string{"*.go"}
+	c.Outputs = []string{"coverage.out"}
+	c.If = "env.CI"
+	c.Always = true
+	c.Hooks = hooks.Set{OnSuccess: []hooks.Hook{{Run: "echo ok"}}}
+	c.Secret = true
+	c.Secrets = secrets.New()
+	c.AcceptInput = true
+
+	c.Status = StatusFailed
+	c.AppendOutput("FAIL")
+	c.ExitCode = 2
+	c.Error = errors.New("exit status 2")
+	c.SkipReason = "skipped"
+	c.WorkingDir = "/tmp"
+
+	fresh := c.Rerun()
+	if fresh == c {
+		t.Fatal("Rerun returned the same command")
+	}
+
+	same := fresh.ID == c.ID &&
+		fresh.Raw == c.Raw &&
+		fresh.StepID == c.StepID &&
+		fresh.Name == c.Name &&
+		fresh.Group == c.Group &&
+		fresh.Pause == c.Pause &&
+		slices.Equal(fresh.Inputs, c.Inputs) &&
+		slices.Equal(fresh.Outputs, c.Outputs) &&
+		fresh.If == c.If &&
+		fresh.Always == c.Always &&
+		slices.Equal(fresh.Hooks.OnSuccess, c.Hooks.OnSuccess) &&
+		fresh.Secret == c.Secret &&
+		fresh.Secrets == c.Secrets &&
+		fresh.AcceptInput == c.AcceptInput
+	if !same {
+		t.Errorf("Rerun changed the step: got %+v, want %+v", fresh, c)
+	}
+
+	if fresh.Status != StatusPending || len(fresh.Output) != 0 || fresh.ExitCode != 0 ||
+		fresh.Error != nil || fresh.SkipReason != "" || fresh.WorkingDir != "" {
+		t.Errorf("Rerun kept the previous run: %+v", fresh)
+	}
+}
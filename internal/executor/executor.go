@@ -1,7 +1,6 @@
 package executor
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"os"
@@ -20,6 +19,30 @@ type Logger interface {
 	LogCommandEnd(cmd *Command)
 }
 
+// Loggers passes every entry on to each of its loggers in turn
+type Loggers []Logger
+
+// LogCommandStart passes the start of a command on to each logger
+func (l Loggers) LogCommandStart(cmd *Command) {
+	for _, logger := range l {
+		logger.LogCommandStart(cmd)
+	}
+}
+
+// LogCommandOutput passes a line of output on to each logger
+func (l Loggers) LogCommandOutput(cmdID int, line string) {
+	for _, logger := range l {
+		logger.LogCommandOutput(cmdID, line)
+	}
+}
+
+// LogCommandEnd passes the end of a command on to each logger
+func (l Loggers) LogCommandEnd(cmd *Command) {
+	for _, logger := range l {
+		logger.LogCommandEnd(cmd)
+	}
+}
+
 // CommandStartedMsg is sent when a command starts executing
 type CommandStartedMsg struct {
 	Index int
@@ -36,8 +59,17 @@ type CommandCompletedMsg struct {
 // TickMsg is sent periodically to refresh the UI and show streaming output
 type TickMsg time.Time
 
-// ExecuteCommand runs a command and returns a tea.Cmd that streams output
-func ExecuteCommand(index int, cmd *Command, workingDir string, logger Logger) tea.Cmd {
+// DefaultShell returns the user's login shell, falling back to /bin/sh
+func DefaultShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// ExecuteCommand runs a command and returns a tea.Cmd that streams output.
+// An empty shell uses DefaultShell.
+func ExecuteCommand(index int, cmd *Command, workingDir string, shell string, logger Logger) tea.Cmd {
 	return func() tea.Msg {
 		// Store working directory
 		cmd.WorkingDir = workingDir
@@ -103,9 +135,8 @@ func ExecuteCommand(index int, cmd *Command, workingDir string, logger Logger) t
 
 		// Regular command execution
 		// Use the user's shell to support aliases and other shell features
-		shell := os.Getenv("SHELL")
 		if shell == "" {
-			shell = "/bin/sh"
+			shell = DefaultShell()
 		}
 
 		// Build command that sources shell config to load aliases
@@ -128,6 +159,9 @@ func ExecuteCommand(index int, cmd *Command, workingDir string, logger Logger) t
 			execCmd.Dir = workingDir
 		}
 
+		// Run in a separate process group so the command can be paused as a whole
+		setProcessGroup(execCmd)
+
 		// Get pipes for stdout and stderr
 		stdoutPipe, err := execCmd.StdoutPipe()
 		if err != nil {
@@ -169,6 +203,31 @@ func ExecuteCommand(index int, cmd *Command, workingDir string, logger Logger) t
 			}
 		}
 
+		// Connect stdin so input can be sent to prompts; otherwise it reads
+		// end-of-file right away
+		var stdinPipe io.WriteCloser
+		if cmd.AcceptInput {
+			stdinPipe, err = execCmd.StdinPipe()
+			if err != nil {
+				cmd.Status = StatusFailed
+				cmd.Error = err
+				cmd.EndTime = time.Now()
+				cmd.ExitCode = -1
+
+				// Log command end
+				if logger != nil {
+					logger.LogCommandEnd(cmd)
+				}
+
+				return CommandCompletedMsg{
+					Index:    index,
+					ExitCode: -1,
+					Error:    err,
+					NewDir:   "",
+				}
+			}
+		}
+
 		// Start the command
 		if err := execCmd.Start(); err != nil {
 			cmd.Status = StatusFailed
@@ -189,6 +248,11 @@ func ExecuteCommand(index int, cmd *Command, workingDir string, logger Logger) t
 			}
 		}
 
+		cmd.pid = execCmd.Process.Pid
+		if stdinPipe != nil {
+			cmd.setInput(stdinPipe)
+		}
+
 		// Stream output from both stdout and stderr
 		var wg sync.WaitGroup
 		wg.Add(2)
@@ -198,12 +262,22 @@ func ExecuteCommand(index int, cmd *Command, workingDir string, logger Logger) t
 
 		// Wait for output streaming to complete
 		wg.Wait()
+		cmd.setInput(nil)
+		cmd.ioMu.Lock()
+		cmd.flushPartial(logger)
+		cmd.ioMu.Unlock()
 
 		// Wait for the command to finish
 		err = execCmd.Wait()
 
 		// Record end time
 		cmd.EndTime = time.Now()
+		cmd.pid = 0
+		if !cmd.pausedAt.IsZero() {
+			// Killed while paused
+			cmd.pausedTotal += cmd.EndTime.Sub(cmd.pausedAt)
+			cmd.pausedAt = time.Time{}
+		}
 
 		// Get exit code
 		exitCode := 0
@@ -238,19 +312,21 @@ func ExecuteCommand(index int, cmd *Command, workingDir string, logger Logger) t
 	}
 }
 
-// streamOutput reads lines from a pipe and appends them to the command's output
+// streamOutput reads from a pipe and appends complete lines to the command's
+// output. Text without a trailing newline, such as a prompt, is kept as the
+// partial line so it can be shown before the line is finished.
 func streamOutput(pipe io.ReadCloser, cmd *Command, wg *sync.WaitGroup, logger Logger) {
 	defer wg.Done()
 	defer pipe.Close()
 
-	scanner := bufio.NewScanner(pipe)
-	for scanner.Scan() {
-		line := scanner.Text()
-		cmd.AppendOutput(line)
-
-		// Log the output line
-		if logger != nil {
-			logger.LogCommandOutput(cmd.ID, line)
+	buf := make([]byte, 4096)
+	for {
+		n, err := pipe.Read(buf)
+		if n > 0 {
+			cmd.writeOutput(string(buf[:n]), logger)
+		}
+		if err != nil {
+			return
 		}
 	}
 }
@@ -269,10 +345,12 @@ func shellQuote(s string) string {
 	return "'" + s + "'"
 }
 
+// WorkingDirMarker starts the line the shell wrapper prints with the working
+// directory a command ended in. It is logged, but not kept in the output.
+const WorkingDirMarker = "__LAZYCOMMANDS_PWD__:"
+
 // extractWorkingDir extracts and removes the working directory marker from command output
 func extractWorkingDir(cmd *Command) string {
-	const marker = "__LAZYCOMMANDS_PWD__:"
-
 	// Check if output has the marker in the last few lines
 	if len(cmd.Output) == 0 {
 		return ""
@@ -281,9 +359,9 @@ func extractWorkingDir(cmd *Command) string {
 	// Look through the last few lines for the marker
 	for i := len(cmd.Output) - 1; i >= 0 && i >= len(cmd.Output)-5; i-- {
 		line := cmd.Output[i]
-		if strings.HasPrefix(line, marker) {
+		if strings.HasPrefix(line, WorkingDirMarker) {
 			// Extract the directory path
-			dir := strings.TrimPrefix(line, marker)
+			dir := strings.TrimPrefix(line, WorkingDirMarker)
 			dir = strings.TrimSpace(dir)
 
 			// Remove this line from output
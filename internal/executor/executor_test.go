@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/secrets"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSecretStepChangesDirectory(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", dir)
+
+	cmd := NewCommand(0, "echo s3cr3t-token; cd sub")
+	cmd.Secret = true
+	cmd.Secrets = secrets.New()
+
+	msg, ok := ExecuteCommand(0, cmd, dir, bash, nil)().(CommandCompletedMsg)
+	if !ok || msg.Error != nil {
+		t.Fatalf("the step failed: %+v", msg)
+	}
+	if msg.NewDir != sub {
+		t.Errorf("NewDir is %q, want %q", msg.NewDir, sub)
+	}
+	if got := cmd.Secrets.Mask(sub); got != sub {
+		t.Errorf("the directory is masked as %q", got)
+	}
+	if got := cmd.Secrets.Mask("s3cr3t-token"); got == "s3cr3t-token" {
+		t.Error("the step's output is not registered as a secret")
+	}
+	if want := []string{secrets.Placeholder}; !slices.Equal(cmd.Output, want) {
+		t.Errorf("output is %q, want %q", cmd.Output, want)
+	}
+}
+
+func TestStoppedOnTerminal(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("processes cannot be inspected without /proc")
+	}
+
+	// A process reading the terminal from the background gets SIGTTIN
+	cmd := NewCommand(0, "kill -TTIN $$")
+	done := make(chan tea.Msg, 1)
+	go func() {
+		done <- ExecuteCommand(0, cmd, "", "/bin/sh", nil)()
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cmd.StoppedOnTerminal() {
+		if time.Now().After(deadline) {
+			cmd.Cancel()
+			<-done
+			t.Fatal("the stopped step was not noticed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	cmd.Cancel()
+	<-done
+	if cmd.StoppedOnTerminal() {
+		t.Error("a finished step counts as stopped")
+	}
+}
@@ -0,0 +1,95 @@
+package executor
+
+import "time"
+
+// Group is a run of consecutive commands that share a group name
+type Group struct {
+	Name     string
+	Start    int // Index of the group's first command
+	Commands []*Command
+}
+
+// Groups returns the groups formed by consecutive commands with the same
+// non-empty Group name, in order
+func Groups(commands []*Command) []Group {
+	var groups []Group
+	for i, cmd := range commands {
+		if cmd.Group == "" {
+			continue
+		}
+		if n := len(groups); n > 0 && groups[n-1].Name == cmd.Group && groups[n-1].Start+len(groups[n-1].Commands) == i {
+			groups[n-1].Commands = append(groups[n-1].Commands, cmd)
+			continue
+		}
+		groups = append(groups, Group{Name: cmd.Group, Start: i, Commands: []*Command{cmd}})
+	}
+	return groups
+}
+
+// Status aggregates the statuses of the group's commands. A failure anywhere
+// marks the group failed; a group that has started but not finished is running.
+func (g Group) Status() CommandStatus {
+	counts := make(map[CommandStatus]int)
+	for _, cmd := range g.Commands {
+		counts[cmd.Status]++
+	}
+
+	total := len(g.Commands)
+	switch {
+	case total == 0:
+		// Every step was deleted before it ran
+		return StatusSkipped
+	case counts[StatusFailed] > 0:
+		return StatusFailed
+	case counts[StatusWaiting] > 0:
+		return StatusWaiting
+	case counts[StatusPaused] > 0:
+		return StatusPaused
+	case counts[StatusRunning] > 0:
+		return StatusRunning
+	case counts[StatusCached] == total:
+		return StatusCached
+	case counts[StatusCompleted]+counts[StatusCached] == total:
+		return StatusCompleted
+	case counts[StatusSkipped] == total:
+		return StatusSkipped
+	case counts[StatusPending] > 0 && counts[StatusCompleted]+counts[StatusCached] > 0:
+		// Between two of its steps
+		return StatusRunning
+	case counts[StatusPending] > 0:
+		return StatusPending
+	default:
+		// Finished early: some steps were skipped
+		return StatusSkipped
+	}
+}
+
+// Done returns the number of commands that have finished
+func (g Group) Done() int {
+	done := 0
+	for _, cmd := range g.Commands {
+		if cmd.Status.Succeeded() || cmd.Status == StatusFailed {
+			done++
+		}
+	}
+	return done
+}
+
+// Started reports whether any command in the group has started
+func (g Group) Started() bool {
+	for _, cmd := range g.Commands {
+		if !cmd.StartTime.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration returns the total run time of the group's commands
+func (g Group) Duration() time.Duration {
+	var total time.Duration
+	for _, cmd := range g.Commands {
+		total += cmd.Duration()
+	}
+	return total
+}
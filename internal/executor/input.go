@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/alameenkhader/lazycommands/internal/secrets"
+)
+
+// maxPartialLine is the longest unterminated output kept before it is
+// treated as a complete line
+const maxPartialLine = 64 * 1024
+
+// errNoInput is returned when the command's stdin is not connected
+var errNoInput = errors.New("the command is not reading input")
+
+// AcceptsInput reports whether input can be sent to the running command
+func (c *Command) AcceptsInput() bool {
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+	return c.stdin != nil
+}
+
+// SendInput writes a line to the command's stdin. The unfinished output line,
+// usually the prompt being answered, is ended as a terminal would end it. The
+// input itself is not added to the output, since it may be a password.
+func (c *Command) SendInput(line string, logger Logger) error {
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+
+	if c.stdin == nil {
+		return errNoInput
+	}
+	if _, err := io.WriteString(c.stdin, line+"\n"); err != nil {
+		return err
+	}
+	c.flushPartial(logger)
+	return nil
+}
+
+// CloseInput closes the command's stdin so it reads end-of-file
+func (c *Command) CloseInput() error {
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+
+	if c.stdin == nil {
+		return errNoInput
+	}
+	err := c.stdin.Close()
+	c.stdin = nil
+	return err
+}
+
+// PartialLine returns output that has not been ended by a newline yet, such
+// as a prompt waiting for input
+func (c *Command) PartialLine() string {
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+	if c.Secret && c.partial != "" {
+		return secrets.Placeholder
+	}
+	return c.Secrets.Mask(c.partial)
+}
+
+// setInput connects the command's stdin while it runs; nil disconnects it
+func (c *Command) setInput(w io.WriteCloser) {
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+	if c.stdin != nil && w == nil {
+		c.stdin.Close()
+	}
+	c.stdin = w
+}
+
+// writeOutput adds a chunk of output, appending each complete line. Output
+// from stdout and stderr shares one partial line, as on a terminal.
+func (c *Command) writeOutput(chunk string, logger Logger) {
+	c.ioMu.Lock()
+	defer c.ioMu.Unlock()
+
+	c.partial += chunk
+	for {
+		i := strings.IndexByte(c.partial, '\n')
+		if i < 0 {
+			break
+		}
+		c.appendLine(strings.TrimSuffix(c.partial[:i], "\r"), logger)
+		c.partial = c.partial[i+1:]
+	}
+	if len(c.partial) > maxPartialLine {
+		c.flushPartial(logger)
+	}
+}
+
+// flushPartial ends the partial output line, if any
+func (c *Command) flushPartial(logger Logger) {
+	if c.partial == "" {
+		return
+	}
+	c.appendLine(c.partial, logger)
+	c.partial = ""
+}
+
+// appendLine records a complete output line and logs it, with its secrets
+// masked. The lines of a secret step are registered as secrets themselves.
+// The working directory marker is left as it is, so the directory is read
+// back even from secret steps.
+func (c *Command) appendLine(line string, logger Logger) {
+	if !strings.HasPrefix(line, WorkingDirMarker) {
+		if c.Secret && strings.TrimSpace(line) != "" {
+			c.Secrets.AddValue(line)
+			line = secrets.Placeholder
+		}
+		line = c.Secrets.Mask(line)
+	}
+
+	c.AppendOutput(line)
+	if logger != nil {
+		logger.LogCommandOutput(c.ID, line)
+	}
+}
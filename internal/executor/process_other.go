@@ -0,0 +1,29 @@
+//go:build !unix
+
+package executor
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// errPauseUnsupported is returned when processes cannot be suspended
+var errPauseUnsupported = errors.New("pausing commands is not supported on this platform")
+
+// setProcessGroup is a no-op where process groups are not available
+func setProcessGroup(c *exec.Cmd) {}
+
+// stopProcessGroup is not supported on this platform
+func stopProcessGroup(pid int) error {
+	return errPauseUnsupported
+}
+
+// continueProcessGroup is not supported on this platform
+func continueProcessGroup(pid int) error {
+	return errPauseUnsupported
+}
+
+// groupStopped always reports false where process groups are not available
+func groupStopped(pid int) bool {
+	return false
+}
@@ -0,0 +1,59 @@
+//go:build unix
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group so signals
+// reach every process it spawns. Cancelling kills the whole group.
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	c.Cancel = func() error {
+		return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// stopProcessGroup suspends every process in the group led by pid
+func stopProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGSTOP)
+}
+
+// continueProcessGroup resumes every process in the group led by pid
+func continueProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGCONT)
+}
+
+// groupStopped reports whether a process in the group led by pid is stopped,
+// as one reading the terminal from the background is. Processes are found
+// through /proc, so where it is missing this is always false.
+func groupStopped(pid int) bool {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return false
+	}
+	pgrp := strconv.Itoa(pid)
+	for _, e := range entries {
+		if e.Name()[0] < '0' || e.Name()[0] > '9' {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// The process name may contain spaces, so the state, parent and
+		// group fields are read after its closing parenthesis
+		stat := string(data)
+		fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+		if len(fields) >= 3 && fields[0] == "T" && fields[2] == pgrp {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+// Package expr evaluates the conditions of conditional workflow steps, such as
+// `failure() && steps.test.status == "failed"` or `env.CI == "true"`.
+// Unless a condition calls success(), failure() or always(), or checks a
+// step's status or exit code, success() is implied: the step only runs while
+// no step has failed.
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Context supplies the values an expression can refer to
+type Context struct {
+	Steps   map[string]Step     // Steps by id
+	Env     func(string) string // Environment lookup (os.Getenv)
+	Failed  bool                // A step has failed so far
+	Cleanup bool                // The step is a cleanup step, which runs after failures too; success() is not implied
+}
+
+// Step is what an expression can see of a step
+type Step struct {
+	Status   string // Lower-case status: pending, completed, failed, skipped, cached...
+	ExitCode int
+	Output   string // Captured output, lines joined with newlines
+}
+
+// Expr is a parsed expression
+type Expr struct {
+	source string
+	root   node
+	steps  []string
+	status bool // Calls success(), failure() or always(), or reads a step's status or exit code
+}
+
+// String returns the expression's source
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Steps returns the ids of the steps the expression refers to
+func (e *Expr) Steps() []string {
+	return e.steps
+}
+
+// ChecksStatus reports whether the expression decides for itself how earlier
+// failures count, by calling success(), failure() or always(), or by checking
+// a step's status or exit code
+func (e *Expr) ChecksStatus() bool {
+	return e.status
+}
+
+// Eval evaluates the expression and reports whether it holds. Unless it
+// checks the status itself or belongs to a cleanup step, it only holds while
+// no step has failed, as if it were written success() && (...).
+func (e *Expr) Eval(ctx Context) (bool, error) {
+	if !e.status && !ctx.Cleanup && ctx.Failed {
+		return false, nil
+	}
+	v, err := e.root.eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// node is a part of the syntax tree. Values are strings, float64 or bool.
+type node interface {
+	eval(ctx Context) (any, error)
+}
+
+type literal struct{ value any }
+
+type stepRef struct{ id, field string }
+
+type envRef struct{ name string }
+
+type not struct{ operand node }
+
+type logical struct {
+	op          string
+	left, right node
+}
+
+type compare struct {
+	op          string
+	left, right node
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func (n literal) eval(Context) (any, error) {
+	return n.value, nil
+}
+
+func (n stepRef) eval(ctx Context) (any, error) {
+	step, ok := ctx.Steps[n.id]
+	if !ok {
+		return nil, fmt.Errorf("unknown step %q", n.id)
+	}
+	switch n.field {
+	case "status":
+		return step.Status, nil
+	case "exit_code":
+		return float64(step.ExitCode), nil
+	default:
+		return step.Output, nil
+	}
+}
+
+func (n envRef) eval(ctx Context) (any, error) {
+	if ctx.Env == nil {
+		return "", nil
+	}
+	return ctx.Env(n.name), nil
+}
+
+func (n not) eval(ctx Context) (any, error) {
+	v, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+func (n logical) eval(ctx Context) (any, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit like the shell and most languages
+	if n.op == "&&" && !truthy(left) || n.op == "||" && truthy(left) {
+		return truthy(left), nil
+	}
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(right), nil
+}
+
+func (n compare) eval(ctx Context) (any, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	// Ordering needs numbers; env values are converted from their text
+	a, okA := number(left)
+	b, okB := number(right)
+	if !okA || !okB {
+		return nil, fmt.Errorf("%s needs numbers, got %s and %s", n.op, describe(left), describe(right))
+	}
+	switch n.op {
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	default:
+		return a >= b, nil
+	}
+}
+
+func (n call) eval(ctx Context) (any, error) {
+	switch n.name {
+	case "success":
+		return !ctx.Failed, nil
+	case "failure":
+		return ctx.Failed, nil
+	case "always":
+		return true, nil
+	}
+
+	// contains(haystack, needle)
+	haystack, err := n.args[0].eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	needle, err := n.args[1].eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Contains(text(haystack), text(needle)), nil
+}
+
+// equal compares numbers numerically and everything else as text
+func equal(a, b any) bool {
+	if x, ok := a.(float64); ok {
+		if y, ok := number(b); ok {
+			return x == y
+		}
+	}
+	if y, ok := b.(float64); ok {
+		if x, ok := number(a); ok {
+			return x == y
+		}
+	}
+	return text(a) == text(b)
+}
+
+// truthy reports whether a value counts as true: true, a non-empty string or
+// a non-zero number
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	default:
+		return false
+	}
+}
+
+// number converts a value to a number, parsing strings
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// text converts a value to its string form
+func text(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// describe formats a value for error messages
+func describe(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return text(v)
+}
@@ -0,0 +1,185 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+// testContext has a passing build, a failed test and CI set
+func testContext(failed bool) Context {
+	return Context{
+		Steps: map[string]Step{
+			"build": {Status: "completed", ExitCode: 0, Output: "built ok"},
+			"test":  {Status: "failed", ExitCode: 2, Output: "FAIL: TestParse\nFAIL: TestMatch"},
+		},
+		Env: func(name string) string {
+			if name == "CI" {
+				return "true"
+			}
+			return ""
+		},
+		Failed: failed,
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`steps.build.status == "completed"`, true},
+		{`steps.build.status != "completed"`, false},
+		{`steps.test.exit_code == 2`, true},
+		{`steps.test.exit_code == "2"`, true},
+		{`steps.test.exit_code > 1 && steps.test.exit_code <= 2`, true},
+		{`steps.build.exit_code >= 1`, false},
+		{`contains(steps.test.output, "TestMatch")`, true},
+		{`contains(steps.build.output, "FAIL")`, false},
+		{`env.CI == "true"`, true},
+		{`env.CI`, true},
+		{`env.MISSING`, false},
+		{`!env.MISSING`, true},
+		{`false || steps.build.status == "completed"`, true},
+		{`!(true && false)`, true},
+		{`true || steps.missing.status == "failed"`, true},
+		{`'single' == "single"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			e, err := Parse(tt.source)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got, err := e.Eval(testContext(false))
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`steps.missing.status == "failed"`, `unknown step "missing"`},
+		{`steps.build.output > 1`, "needs numbers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			e, err := Parse(tt.source)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			_, err = e.Eval(testContext(false))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Eval error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{``, "unexpected end of expression at column 1"},
+		{`steps.build`, `expected "."`},
+		{`steps.build.size`, `unknown step field "size" at column 13`},
+		{`branch == "main"`, `unknown name "branch" at column 1`},
+		{`success(1)`, "success"},
+		{`contains("a")`, "contains"},
+		{`(true`, `expected ")"`},
+		{`"open`, "column 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := Parse(tt.source)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestImpliedSuccess(t *testing.T) {
+	tests := []struct {
+		source  string
+		failed  bool
+		cleanup bool
+		want    bool
+	}{
+		// Without a status check, a failure stops the step
+		{`env.CI == "true"`, false, false, true},
+		{`env.CI == "true"`, true, false, false},
+		// Unless the step is a cleanup step
+		{`env.CI == "true"`, true, true, true},
+		// Conditions that check the status decide for themselves
+		{`success()`, false, false, true},
+		{`success()`, true, false, false},
+		{`failure()`, false, false, false},
+		{`failure()`, true, false, true},
+		{`failure() && steps.test.status == "failed"`, true, false, true},
+		{`always()`, true, false, true},
+		{`always() && env.CI == "true"`, true, false, true},
+		{`!failure() || env.CI == "true"`, true, false, true},
+		{`success() || steps.test.exit_code == 2`, true, false, true},
+		// So do conditions on a step's status or exit code
+		{`steps.test.status == "failed"`, true, false, true},
+		{`steps.test.exit_code == 2 && env.CI == "true"`, true, false, true},
+		{`steps.build.status == "failed"`, true, false, false},
+		// Output alone says nothing about failures
+		{`contains(steps.test.output, "FAIL")`, true, false, false},
+	}
+
+	for _, tt := range tests {
+		ctx := testContext(tt.failed)
+		ctx.Cleanup = tt.cleanup
+		e, err := Parse(tt.source)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.source, err)
+		}
+		got, err := e.Eval(ctx)
+		if err != nil {
+			t.Fatalf("Eval(%q): %v", tt.source, err)
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q) with failed=%v cleanup=%v = %v, want %v", tt.source, tt.failed, tt.cleanup, got, tt.want)
+		}
+	}
+}
+
+func TestChecksStatus(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{`env.CI == "true"`, false},
+		{`contains(steps.test.output, "FAIL")`, false},
+		{`steps.test.status == "failed"`, true},
+		{`steps.test.exit_code != 0`, true},
+		{`success()`, true},
+		{`!failure()`, true},
+		{`env.CI == "true" && always()`, true},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.source)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.source, err)
+		}
+		if got := e.ChecksStatus(); got != tt.want {
+			t.Errorf("ChecksStatus(%q) = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// token kinds
+const (
+	tokEOF = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int // 1-based column
+}
+
+// Parse parses an expression. Step and env references, operators and
+// function names are checked here; whether referenced steps exist is up to
+// the caller (see Steps).
+func Parse(source string) (*Expr, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at column %d", t.text, t.pos)
+	}
+	return &Expr{source: source, root: root, steps: p.steps, status: p.status}, nil
+}
+
+// lex splits the source into tokens
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			var b strings.Builder
+			for end < len(s) && s[end] != c {
+				if s[end] == '\\' && end+1 < len(s) {
+					end++
+				}
+				b.WriteByte(s[end])
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string at column %d", i+1)
+			}
+			tokens = append(tokens, token{tokString, b.String(), i + 1})
+			i = end + 1
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			end := i + 1
+			for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{tokNumber, s[i:end], i + 1})
+			i = end
+		case c == '_' || unicode.IsLetter(rune(c)):
+			end := i + 1
+			for end < len(s) && (s[end] == '_' || s[end] == '-' || unicode.IsLetter(rune(s[end])) || unicode.IsDigit(rune(s[end]))) {
+				end++
+			}
+			tokens = append(tokens, token{tokIdent, s[i:end], i + 1})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", ".", ","} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at column %d", string(c), i+1)
+			}
+			tokens = append(tokens, token{tokOp, op, i + 1})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{tokEOF, "end of expression", len(s) + 1}), nil
+}
+
+// parser is a recursive descent parser over the tokens
+type parser struct {
+	tokens []token
+	next   int
+	steps  []string
+	status bool // success(), failure() or always() was called, or a step's status or exit code read
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokEOF {
+		p.next++
+	}
+	return t
+}
+
+// accept consumes the operator op if it is next
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.next++
+		return true
+	}
+	return false
+}
+
+// expect consumes the operator op or fails
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return fmt.Errorf("expected %q at column %d, found %q", op, t.pos, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{"||", left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{"&&", left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return not{operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return compare{op, left, right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.take()
+	switch t.kind {
+	case tokString:
+		return literal{t.text}, nil
+	case tokNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at column %d", t.text, t.pos)
+		}
+		return literal{n}, nil
+	case tokOp:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		}
+	case tokIdent:
+		return p.parseName(t)
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression at column %d", t.pos)
+	}
+	return nil, fmt.Errorf("unexpected %q at column %d", t.text, t.pos)
+}
+
+// parseName parses what starts with an identifier: a literal, a function
+// call or a steps/env reference
+func (p *parser) parseName(t token) (node, error) {
+	switch t.text {
+	case "true":
+		return literal{true}, nil
+	case "false":
+		return literal{false}, nil
+	case "success", "failure", "always":
+		p.status = true
+		return p.parseCall(t)
+	case "contains":
+		return p.parseCall(t)
+	case "steps":
+		id, err := p.member()
+		if err != nil {
+			return nil, err
+		}
+		field, err := p.member()
+		if err != nil {
+			return nil, err
+		}
+		switch field.text {
+		case "status", "exit_code", "output":
+		default:
+			return nil, fmt.Errorf("unknown step field %q at column %d (use status, exit_code or output)", field.text, field.pos)
+		}
+		if field.text != "output" {
+			p.status = true
+		}
+		p.steps = append(p.steps, id.text)
+		return stepRef{id.text, field.text}, nil
+	case "env":
+		name, err := p.member()
+		if err != nil {
+			return nil, err
+		}
+		return envRef{name.text}, nil
+	}
+	return nil, fmt.Errorf("unknown name %q at column %d (use steps, env, success(), failure(), always() or contains())", t.text, t.pos)
+}
+
+// member parses ".name"
+func (p *parser) member() (token, error) {
+	if err := p.expect("."); err != nil {
+		return token{}, err
+	}
+	t := p.take()
+	if t.kind != tokIdent {
+		return token{}, fmt.Errorf("expected a name at column %d, found %q", t.pos, t.text)
+	}
+	return t, nil
+}
+
+// parseCall parses the arguments of a function call
+func (p *parser) parseCall(name token) (node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []node
+	if !p.accept(")") {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	want := 0
+	if name.text == "contains" {
+		want = 2
+	}
+	if len(args) != want {
+		return nil, fmt.Errorf("%s() takes %d arguments, got %d", name.text, want, len(args))
+	}
+	return call{name.text, args}, nil
+}
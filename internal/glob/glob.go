@@ -0,0 +1,161 @@
+// Package glob matches slash-separated paths against glob patterns with `**`
+// support and finds the files matching them
+package glob
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Pattern is a compiled glob. `*` and `?` match within a path segment and
+// `**` matches any number of directories.
+type Pattern struct {
+	glob string
+	re   *regexp.Regexp
+	base string // Leading directories without wildcards
+}
+
+// Compile parses a glob relative to some root directory
+func Compile(glob string) (*Pattern, error) {
+	glob = strings.TrimPrefix(path.Clean(filepath.ToSlash(glob)), "./")
+	re, err := compile(glob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", glob, err)
+	}
+	return &Pattern{glob: glob, re: re, base: base(glob)}, nil
+}
+
+// CompileAll parses every glob, stopping at the first invalid one
+func CompileAll(globs []string) ([]*Pattern, error) {
+	patterns := make([]*Pattern, 0, len(globs))
+	for _, g := range globs {
+		p, err := Compile(g)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// String returns the glob the pattern was compiled from
+func (p *Pattern) String() string {
+	return p.glob
+}
+
+// Match reports whether a slash-separated relative path matches the pattern
+func (p *Pattern) Match(rel string) bool {
+	return p.re.MatchString(rel)
+}
+
+// Walk calls fn for every file under root matching any of the patterns, in
+// lexical order and once per file. Paths are relative to root and use forward
+// slashes. Directories named .git are not searched.
+func Walk(root string, patterns []*Pattern, fn func(rel string, d fs.DirEntry)) {
+	seen := make(map[string]bool)
+	for _, p := range patterns {
+		filepath.WalkDir(filepath.Join(root, p.base), func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if seen[rel] || !matchAny(patterns, rel) {
+				return nil
+			}
+			seen[rel] = true
+			fn(rel, d)
+			return nil
+		})
+	}
+}
+
+// Files returns the files under root matching any of the patterns, sorted
+func Files(root string, patterns []*Pattern) []string {
+	var files []string
+	Walk(root, patterns, func(rel string, d fs.DirEntry) {
+		files = append(files, rel)
+	})
+	sort.Strings(files)
+	return files
+}
+
+// matchAny reports whether rel matches any of the patterns
+func matchAny(patterns []*Pattern, rel string) bool {
+	for _, p := range patterns {
+		if p.Match(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// base returns the leading directories of a glob that contain no wildcards,
+// which is where searching for it starts
+func base(glob string) string {
+	segments := strings.Split(glob, "/")
+	var fixed []string
+	for _, s := range segments[:len(segments)-1] {
+		if strings.ContainsAny(s, "*?[") {
+			break
+		}
+		fixed = append(fixed, s)
+	}
+	return strings.Join(fixed, "/")
+}
+
+// compile converts a glob into a regular expression matching whole paths
+func compile(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [")
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
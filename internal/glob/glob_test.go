@@ -0,0 +1,76 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"*.go", "main.go.orig", false},
+		{"src/*.js", "src/app.js", true},
+		{"src/*.js", "src/lib/app.js", false},
+		{"src/**/*.js", "src/app.js", true},
+		{"src/**/*.js", "src/lib/deep/app.js", true},
+		{"src/**/*.js", "test/app.js", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "internal/glob/glob.go", true},
+		{"web/src/**", "web/src/a/b.css", true},
+		{"web/src/**", "web/other.css", false},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file10.txt", false},
+		{"file?.txt", "file/.txt", false},
+		{"[ab].txt", "a.txt", true},
+		{"[ab].txt", "c.txt", false},
+		{"[!ab].txt", "c.txt", true},
+		{"[!ab].txt", "a.txt", false},
+		{"./go.mod", "go.mod", true},
+		{"docs/../go.sum", "go.sum", true},
+		{"a+b(c).txt", "a+b(c).txt", true},
+		{"a.txt", "abtxt", false},
+	}
+
+	for _, tt := range tests {
+		p, err := Compile(tt.glob)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tt.glob, err)
+		}
+		if got := p.Match(tt.path); got != tt.want {
+			t.Errorf("Compile(%q).Match(%q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, glob := range []string{"[abc", "src/[.go"} {
+		if _, err := Compile(glob); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", glob)
+		}
+	}
+}
+
+func TestBase(t *testing.T) {
+	tests := []struct {
+		glob string
+		want string
+	}{
+		{"*.go", ""},
+		{"go.mod", ""},
+		{"web/src/**", "web/src"},
+		{"api/**/*.go", "api"},
+		{"a/b*/c/*.txt", "a"},
+	}
+
+	for _, tt := range tests {
+		p, err := Compile(tt.glob)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tt.glob, err)
+		}
+		if p.base != tt.want {
+			t.Errorf("base of %q = %q, want %q", tt.glob, p.base, tt.want)
+		}
+	}
+}
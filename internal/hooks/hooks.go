@@ -0,0 +1,170 @@
+// Package hooks runs commands and notifiers when a step or the whole run
+// succeeds or fails
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notifiers built into hooks
+const (
+	NotifyDesktop = "desktop" // Desktop notification through notify-send
+	NotifyBell    = "bell"    // Terminal bell
+)
+
+// commandTimeout bounds how long a hook command may hold up the run, or
+// exiting at its end
+const commandTimeout = time.Minute
+
+// Hook is one action: a shell command, a built-in notifier or a webhook.
+// Exactly one of the fields is set.
+type Hook struct {
+	Run     string `json:"run,omitempty"`     // Shell command, given the event in environment variables
+	Notify  string `json:"notify,omitempty"`  // Built-in notifier: desktop or bell
+	Webhook string `json:"webhook,omitempty"` // URL the event is POSTed to as JSON
+}
+
+// Set holds the hooks for each outcome
+type Set struct {
+	OnSuccess []Hook
+	OnFailure []Hook
+}
+
+// Empty reports whether the set has no hooks
+func (s Set) Empty() bool {
+	return len(s.OnSuccess) == 0 && len(s.OnFailure) == 0
+}
+
+// For returns the hooks for an outcome
+func (s Set) For(success bool) []Hook {
+	if success {
+		return s.OnSuccess
+	}
+	return s.OnFailure
+}
+
+// Merge returns the hooks of both sets, those of s first
+func (s Set) Merge(other Set) Set {
+	return Set{
+		OnSuccess: append(append([]Hook(nil), s.OnSuccess...), other.OnSuccess...),
+		OnFailure: append(append([]Hook(nil), s.OnFailure...), other.OnFailure...),
+	}
+}
+
+// ParseNotifier reads a notifier given on the command line: desktop, bell
+// or a webhook URL
+func ParseNotifier(s string) (Hook, error) {
+	h := Hook{Notify: s}
+	if strings.Contains(s, "://") {
+		h = Hook{Webhook: s}
+	}
+	return h, h.Validate()
+}
+
+// Validate checks that the hook has exactly one valid action
+func (h Hook) Validate() error {
+	set := 0
+	for _, field := range []string{h.Run, h.Notify, h.Webhook} {
+		if strings.TrimSpace(field) != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New(`a hook has exactly one of "run", "notify" or "webhook"`)
+	}
+
+	switch {
+	case h.Notify != "" && h.Notify != NotifyDesktop && h.Notify != NotifyBell:
+		return fmt.Errorf("unknown notifier %q (valid: %s, %s)", h.Notify, NotifyDesktop, NotifyBell)
+	case h.Webhook != "":
+		u, err := url.Parse(h.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %q is not an http or https URL", h.Webhook)
+		}
+	}
+	return nil
+}
+
+// String describes the hook for the log
+func (h Hook) String() string {
+	switch {
+	case h.Notify != "":
+		return "notify " + h.Notify
+	case h.Webhook != "":
+		// Webhook URLs often carry a token in their path
+		if u, err := url.Parse(h.Webhook); err == nil {
+			return "webhook " + u.Scheme + "://" + u.Host
+		}
+		return "webhook"
+	default:
+		return h.Run
+	}
+}
+
+// Event describes what a hook is reacting to
+type Event struct {
+	Success  bool
+	Step     string // The step that finished, or for the run the step that failed
+	Command  string // Command of Step
+	ExitCode int
+	Log      string // Path of the debug log, if there is one
+	Message  string // One-line description for notifications
+}
+
+// Status names the outcome, as passed to hooks
+func (e Event) Status() string {
+	if e.Success {
+		return "success"
+	}
+	return "failure"
+}
+
+// Env returns the environment a hook command runs with: the current one plus
+// the event's LAZYCOMMANDS_* variables
+func (e Event) Env() []string {
+	return append(os.Environ(),
+		"LAZYCOMMANDS_STATUS="+e.Status(),
+		"LAZYCOMMANDS_STEP="+e.Step,
+		"LAZYCOMMANDS_COMMAND="+e.Command,
+		"LAZYCOMMANDS_EXIT_CODE="+strconv.Itoa(e.ExitCode),
+		"LAZYCOMMANDS_LOG="+e.Log,
+		"LAZYCOMMANDS_MESSAGE="+e.Message,
+	)
+}
+
+// Fire runs the hook for an event, returning the output of a hook command.
+// Commands run with shell in dir.
+func (h Hook) Fire(e Event, shell, dir string) (string, error) {
+	switch {
+	case h.Notify == NotifyDesktop:
+		return "", notifyDesktop(e)
+	case h.Notify == NotifyBell:
+		return "", ringBell()
+	case h.Webhook != "":
+		return "", postWebhook(h.Webhook, e)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, shell, "-c", h.Run)
+	cmd.Dir = dir
+	cmd.Env = e.Env()
+	// Kill whatever the hook started too, and stop waiting for output from
+	// processes that escaped the group
+	setProcessGroup(cmd)
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", commandTimeout)
+	}
+	return strings.TrimRight(string(out), "\n"), err
+}
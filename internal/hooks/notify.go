@@ -0,0 +1,92 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// webhookTimeout bounds how long a webhook may hold up the run
+const webhookTimeout = 10 * time.Second
+
+// notifyDesktop shows a desktop notification with notify-send
+func notifyDesktop(e Event) error {
+	title := "lazycommands: run succeeded"
+	urgency := "normal"
+	if !e.Success {
+		title = "lazycommands: run failed"
+		urgency = "critical"
+	}
+
+	out, err := exec.Command("notify-send", "--app-name=lazycommands", "--urgency="+urgency, title, e.Message).CombinedOutput()
+	if err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("notify-send: %v: %s", err, bytes.TrimSpace(out))
+		}
+		return fmt.Errorf("notify-send: %w", err)
+	}
+	return nil
+}
+
+// ringBell rings the terminal bell. It writes to the terminal directly, as
+// stdout belongs to the interface or may be redirected to a file.
+func ringBell() error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		_, err = os.Stderr.WriteString("\a")
+		return err
+	}
+	defer tty.Close()
+	_, err = tty.WriteString("\a")
+	return err
+}
+
+// webhookPayload is the JSON body POSTed to webhooks
+type webhookPayload struct {
+	Status   string `json:"status"`
+	Step     string `json:"step,omitempty"`
+	Command  string `json:"command,omitempty"`
+	ExitCode int    `json:"exit_code"`
+	Log      string `json:"log,omitempty"`
+	Message  string `json:"message"`
+	// Text repeats the message for chat webhooks such as Slack's
+	Text string `json:"text"`
+}
+
+// postWebhook POSTs the event as JSON to target
+func postWebhook(target string, e Event) error {
+	body, err := json.Marshal(webhookPayload{
+		Status:   e.Status(),
+		Step:     e.Step,
+		Command:  e.Command,
+		ExitCode: e.ExitCode,
+		Log:      e.Log,
+		Message:  e.Message,
+		Text:     e.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The error repeats the URL, which may hold a token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
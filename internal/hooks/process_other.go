@@ -0,0 +1,9 @@
+//go:build !unix
+
+package hooks
+
+import "os/exec"
+
+// setProcessGroup is a no-op where process groups are not available; a
+// timeout kills only the shell
+func setProcessGroup(c *exec.Cmd) {}
@@ -1,14 +1,57 @@
 package keys
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
 
 // KeyMap defines the keyboard shortcuts for the application
 type KeyMap struct {
 	Up       key.Binding
 	Down     key.Binding
+	Open     key.Binding
+	Back     key.Binding
 	Continue key.Binding
 	Stop     key.Binding
+	Help     key.Binding
 	Quit     key.Binding
+
+	// Command list
+	Fold key.Binding
+
+	// Running command
+	Suspend key.Binding
+	Attach  key.Binding
+	Detach  key.Binding
+
+	// Pre-run review
+	ToggleStep key.Binding
+	StartHere  key.Binding
+
+	// Editing the pending queue
+	Insert   key.Binding
+	Delete   key.Binding
+	Edit     key.Binding
+	MoveUp   key.Binding
+	MoveDown key.Binding
+
+	// Output viewer
+	PageUp    key.Binding
+	PageDown  key.Binding
+	Top       key.Binding
+	Bottom    key.Binding
+	Search    key.Binding
+	NextMatch key.Binding
+	PrevMatch key.Binding
+	Follow    key.Binding
+
+	// Clipboard
+	CopyCommand key.Binding
+	CopyOutput  key.Binding
+	CopyMatch   key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings
@@ -16,11 +59,19 @@ func DefaultKeyMap() KeyMap {
 	return KeyMap{
 		Up: key.NewBinding(
 			key.WithKeys("up", "k"),
-			key.WithHelp("↑/k", "move up"),
+			key.WithHelp("↑/k", "up"),
 		),
 		Down: key.NewBinding(
 			key.WithKeys("down", "j"),
-			key.WithHelp("↓/j", "move down"),
+			key.WithHelp("↓/j", "down"),
+		),
+		Open: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "output"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
 		),
 		Continue: key.NewBinding(
 			key.WithKeys("y"),
@@ -30,9 +81,309 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("n"),
 			key.WithHelp("n", "stop"),
 		),
+		Help: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "help"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("q", "ctrl+c"),
-			key.WithHelp("q/ctrl+c", "quit"),
+			key.WithHelp("q", "quit"),
+		),
+		Fold: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "fold"),
+		),
+		Suspend: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pause"),
+		),
+		Attach: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "attach input"),
+		),
+		Detach: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "detach"),
+		),
+		ToggleStep: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "skip/run"),
+		),
+		StartHere: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "start here"),
+		),
+		Insert: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "add step"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "delete"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit"),
+		),
+		MoveUp: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "move up"),
 		),
+		MoveDown: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "move down"),
+		),
+		PageUp: key.NewBinding(
+			key.WithKeys("pgup", "ctrl+b"),
+			key.WithHelp("pgup", "page up"),
+		),
+		PageDown: key.NewBinding(
+			key.WithKeys("pgdown", "ctrl+f", " "),
+			key.WithHelp("pgdn", "page down"),
+		),
+		Top: key.NewBinding(
+			key.WithKeys("g", "home"),
+			key.WithHelp("g", "top"),
+		),
+		Bottom: key.NewBinding(
+			key.WithKeys("G", "end"),
+			key.WithHelp("G", "bottom"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search"),
+		),
+		NextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next"),
+		),
+		PrevMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "prev"),
+		),
+		Follow: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "follow"),
+		),
+		CopyCommand: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "copy cmd"),
+		),
+		CopyOutput: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "copy output"),
+		),
+		CopyMatch: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "copy match"),
+		),
+	}
+}
+
+// ShortHelp returns the bindings shown in the help bar of the command list.
+// It implements the help.KeyMap interface.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Open, k.Help, k.Quit}
+}
+
+// FullHelp returns every binding, grouped into columns for the help overlay.
+// It implements the help.KeyMap interface.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Open, k.Fold, k.Back},
+		{k.Insert, k.Edit, k.Delete, k.MoveUp, k.MoveDown},
+		{k.ToggleStep, k.StartHere, k.Continue},
+		{k.Suspend, k.Attach, k.Detach},
+		{k.PageUp, k.PageDown, k.Top, k.Bottom, k.Follow},
+		{k.Search, k.NextMatch, k.PrevMatch},
+		{k.CopyCommand, k.CopyOutput, k.CopyMatch},
+		{k.Help, k.Quit},
+	}
+}
+
+// ListHelp returns the help bar bindings for the command list. Fold is only
+// offered when the list has groups, and pause or resume while a command runs.
+func (k KeyMap) ListHelp(grouped, running, paused bool) []key.Binding {
+	bindings := []key.Binding{k.Up, k.Down, k.Open}
+	if grouped {
+		bindings = append(bindings, k.Fold)
+	}
+	if running {
+		bindings = append(bindings, k.SuspendHelp(paused), k.Attach)
+	}
+	return append(bindings, k.Help, k.Quit)
+}
+
+// SuspendHelp returns the Suspend binding labelled for what it will do next
+func (k KeyMap) SuspendHelp(paused bool) key.Binding {
+	suspend := k.Suspend
+	if paused {
+		suspend.SetHelp(suspend.Help().Key, "resume")
+	}
+	return suspend
+}
+
+// ReviewHelp returns the help bar bindings for the pre-run review
+func (k KeyMap) ReviewHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.ToggleStep, k.StartHere, k.Continue, k.Quit}
+}
+
+// ApprovalHelp returns the help bar bindings while an approval gate waits for an answer
+func (k KeyMap) ApprovalHelp() []key.Binding {
+	approve, decline := k.Continue, k.Stop
+	approve.SetHelp(approve.Help().Key, "approve")
+	decline.SetHelp(decline.Help().Key, "decline")
+	return []key.Binding{approve, decline, k.Up, k.Down, k.Open, k.Help, k.Quit}
+}
+
+// OutputHelp returns the help bar bindings for the output viewer. Follow and
+// attach are only offered while the command is running.
+func (k KeyMap) OutputHelp(running bool) []key.Binding {
+	bindings := []key.Binding{k.PageUp, k.PageDown, k.Search, k.NextMatch}
+	if running {
+		bindings = append(bindings, k.Follow, k.Attach)
+	}
+	return append(bindings, k.Back, k.Help, k.Quit)
+}
+
+// AttachedHelp returns the help bar bindings while keyboard input goes to a
+// running command
+func (k KeyMap) AttachedHelp() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "send line")),
+		key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "end input")),
+		k.Detach,
+	}
+}
+
+// FailureHelp returns the help bar bindings for the failure details screen
+func (k KeyMap) FailureHelp() []key.Binding {
+	return []key.Binding{k.PageUp, k.Search, k.NextMatch, k.CopyOutput, k.Back, k.Help, k.Quit}
+}
+
+// bindings maps configuration names to the bindings they control
+func (k *KeyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":           &k.Up,
+		"down":         &k.Down,
+		"open":         &k.Open,
+		"back":         &k.Back,
+		"continue":     &k.Continue,
+		"stop":         &k.Stop,
+		"help":         &k.Help,
+		"quit":         &k.Quit,
+		"fold":         &k.Fold,
+		"suspend":      &k.Suspend,
+		"attach":       &k.Attach,
+		"detach":       &k.Detach,
+		"toggle_step":  &k.ToggleStep,
+		"start_here":   &k.StartHere,
+		"insert":       &k.Insert,
+		"delete":       &k.Delete,
+		"edit":         &k.Edit,
+		"move_up":      &k.MoveUp,
+		"move_down":    &k.MoveDown,
+		"page_up":      &k.PageUp,
+		"page_down":    &k.PageDown,
+		"top":          &k.Top,
+		"bottom":       &k.Bottom,
+		"search":       &k.Search,
+		"next_match":   &k.NextMatch,
+		"prev_match":   &k.PrevMatch,
+		"follow":       &k.Follow,
+		"copy_command": &k.CopyCommand,
+		"copy_output":  &k.CopyOutput,
+		"copy_match":   &k.CopyMatch,
+	}
+}
+
+// Names returns the configuration names of all bindings, sorted
+func (k KeyMap) Names() []string {
+	names := make([]string, 0)
+	for name := range k.bindings() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Keys returns the keys currently assigned to the named binding
+func (k KeyMap) Keys(name string) ([]string, bool) {
+	b, ok := k.bindings()[name]
+	if !ok {
+		return nil, false
+	}
+	return b.Keys(), true
+}
+
+// Rebind replaces the keys of the named binding, keeping its description
+func (k *KeyMap) Rebind(name string, keys []string) error {
+	b, ok := k.bindings()[name]
+	if !ok {
+		return fmt.Errorf("unknown binding %q", name)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("binding %q needs at least one key", name)
+	}
+	for _, s := range keys {
+		if strings.TrimSpace(s) == "" && s != " " {
+			return fmt.Errorf("binding %q has an empty key", name)
+		}
+	}
+
+	b.SetKeys(keys...)
+	b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+	return nil
+}
+
+// screens lists the bindings that act together on each screen, by
+// configuration name. Approval gates are answered from the command list, so
+// stop shares its default key with next_match, which only the output screens use.
+var screens = []struct {
+	name     string
+	bindings []string
+}{
+	{"command list", []string{
+		"up", "down", "open", "fold", "page_up", "page_down", "top", "bottom",
+		"insert", "edit", "delete", "move_up", "move_down",
+		"suspend", "attach", "continue", "stop", "help", "quit",
+	}},
+	{"review", []string{
+		"up", "down", "open", "fold", "page_up", "page_down", "top", "bottom",
+		"toggle_step", "start_here", "continue", "help", "quit",
+	}},
+	{"output", []string{
+		"up", "down", "page_up", "page_down", "top", "bottom", "follow",
+		"search", "next_match", "prev_match", "copy_command", "copy_output", "copy_match",
+		"suspend", "attach", "back", "help", "quit",
+	}},
+	{"attached input", []string{"detach"}},
+}
+
+// attachedKeys are the fixed keys of the attached input line
+var attachedKeys = map[string]string{"enter": "sending the line", "ctrl+d": "ending the input"}
+
+// Conflicts describes every key bound to more than one action on the same
+// screen, which would make all but one of them unreachable
+func (k KeyMap) Conflicts() []string {
+	bindings := k.bindings()
+	var conflicts []string
+	for _, screen := range screens {
+		owner := make(map[string]string)
+		if screen.name == "attached input" {
+			for key, action := range attachedKeys {
+				owner[key] = action
+			}
+		}
+		for _, name := range screen.bindings {
+			for _, key := range bindings[name].Keys() {
+				if other, ok := owner[key]; ok && other != name {
+					conflicts = append(conflicts, fmt.Sprintf("%q is bound to both %s and %s on the %s screen", key, other, name, screen.name))
+					continue
+				}
+				owner[key] = name
+			}
+		}
 	}
+	return conflicts
 }
@@ -0,0 +1,51 @@
+package keys
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultKeyMapHasNoConflicts(t *testing.T) {
+	// n both declines approval gates and finds the next search match, as
+	// gates are answered from the command list only
+	if conflicts := DefaultKeyMap().Conflicts(); len(conflicts) > 0 {
+		t.Errorf("default key map has conflicts:\n%s", strings.Join(conflicts, "\n"))
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want string // Empty if the binding is allowed
+	}{
+		{"stop", []string{"j"}, `"j" is bound to both down and stop on the command list screen`},
+		{"next_match", []string{"/"}, `"/" is bound to both search and next_match on the output screen`},
+		{"detach", []string{"enter"}, `"enter" is bound to both sending the line and detach on the attached input screen`},
+		{"toggle_step", []string{"y"}, `"y" is bound to both toggle_step and continue on the review screen`},
+		{"help", []string{"esc"}, `"esc" is bound to both back and help on the output screen`},
+		// Bindings on different screens may share keys
+		{"stop", []string{"N"}, ""},
+		{"detach", []string{"q"}, ""},
+		{"copy_output", []string{"a"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"="+strings.Join(tt.keys, ","), func(t *testing.T) {
+			km := DefaultKeyMap()
+			if err := km.Rebind(tt.name, tt.keys); err != nil {
+				t.Fatal(err)
+			}
+			conflicts := km.Conflicts()
+			if tt.want == "" {
+				if len(conflicts) > 0 {
+					t.Errorf("Conflicts = %q, want none", conflicts)
+				}
+				return
+			}
+			if len(conflicts) == 0 || conflicts[0] != tt.want {
+				t.Errorf("Conflicts = %q, want %q first", conflicts, tt.want)
+			}
+		})
+	}
+}
@@ -1,44 +1,130 @@
 package log
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/hooks"
+	"github.com/alameenkhader/lazycommands/internal/secrets"
 )
 
+// Format selects how log entries are written
+type Format string
+
+const (
+	// FormatText writes human-readable lines
+	FormatText Format = "text"
+	// FormatJSON writes one JSON object per line (JSONL)
+	FormatJSON Format = "json"
+)
+
+// ParseFormat validates a log format name
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatText, FormatJSON:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unknown log format %q (valid: %s, %s)", s, FormatText, FormatJSON)
+	}
+}
+
+// Settings controls where logs are written and how long earlier ones are kept
+type Settings struct {
+	Format     Format
+	Disabled   bool      // Write no log at all
+	Dir        string    // Directory for logs; the system temp directory if empty
+	PerProject bool      // Keep each project's logs in a subdirectory of Dir
+	Retention  Retention // Which logs of earlier runs are kept
+	Compress   bool      // Gzip the logs of earlier runs
+}
+
+// dir returns the directory the log is written to, expanding a leading ~
+func (s Settings) dir() string {
+	dir := s.Dir
+	if dir == "" {
+		dir = os.TempDir()
+		if s.PerProject {
+			// Keep project subdirectories out of the top of the temp directory
+			dir = filepath.Join(dir, "lazycommands")
+		}
+	} else if rest, ok := strings.CutPrefix(dir, "~"); ok && (rest == "" || os.IsPathSeparator(rest[0])) {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = home + rest
+		}
+	}
+
+	if s.PerProject {
+		if cwd, err := os.Getwd(); err == nil {
+			dir = ProjectDir(dir, cwd)
+		}
+	}
+	return dir
+}
+
 // Logger handles writing command execution logs to a temporary file
 type Logger struct {
-	file *os.File
-	mu   sync.Mutex
-	path string
+	file   *os.File
+	mu     sync.Mutex
+	path   string
+	format Format
+
+	// group is the open group section; its commands are nested under it
+	group executor.Group
+
+	// secrets are masked in everything written to the log
+	secrets *secrets.Masker
 }
 
-// NewLogger creates a new logger that writes to a file in the system temp directory
-func NewLogger() (*Logger, error) {
+// NewLogger creates a new logger that writes to a file in the configured
+// directory, the system temp directory by default. Logs of earlier runs in the
+// same directory are compressed and pruned as the settings ask.
+func NewLogger(settings Settings) (*Logger, error) {
+	format := settings.Format
+	if format == "" {
+		format = FormatText
+	}
+
+	dir := settings.dir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
 	// Generate log file name with timestamp and PID
+	ext := "log"
+	if format == FormatJSON {
+		ext = "jsonl"
+	}
 	timestamp := time.Now().Format("2006-01-02-150405")
 	pid := os.Getpid()
-	filename := fmt.Sprintf("lazycommands-%s-%d.log", timestamp, pid)
+	filename := fmt.Sprintf("lazycommands-%s-%d.%s", timestamp, pid, ext)
 
-	// Create log file in temp directory
-	logPath := filepath.Join(os.TempDir(), filename)
-	file, err := os.Create(logPath)
+	logPath := filepath.Join(dir, filename)
+	// Only the user can read the log, as commands may print credentials
+	file, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create log file: %w", err)
 	}
 
 	logger := &Logger{
-		file: file,
-		path: logPath,
+		file:   file,
+		path:   logPath,
+		format: format,
 	}
 
 	// Write header
 	logger.writeHeader()
 
+	if !settings.Retention.IsZero() || settings.Compress {
+		prune(dir, logPath, settings.Retention, settings.Compress)
+	}
+
 	return logger, nil
 }
 
@@ -47,13 +133,63 @@ func (l *Logger) writeHeader() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{"event": "run_start"})
+		return
+	}
+
 	header := fmt.Sprintf("LazyCommands Execution Log\nStarted: %s\n%s\n\n",
 		time.Now().Format("2006-01-02 15:04:05"),
 		"=======================================================")
-	l.file.WriteString(header)
+	l.write(header)
+	l.file.Sync()
+}
+
+// SetSecrets masks the registered secrets in every later entry
+func (l *Logger) SetSecrets(m *secrets.Masker) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.secrets = m
+}
+
+// write writes text entries with their secrets masked. The caller must hold l.mu.
+func (l *Logger) write(s string) {
+	l.file.WriteString(l.secrets.Mask(s))
+}
+
+// writeRecord writes a single JSONL entry with the secrets in its string
+// fields masked. The caller must hold l.mu.
+func (l *Logger) writeRecord(rec map[string]any) {
+	l.maskFields(rec)
+	rec["time"] = time.Now().Format(time.RFC3339Nano)
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	l.file.Write(append(data, '\n'))
 	l.file.Sync()
 }
 
+// maskFields masks the secrets in the string fields of rec, including those
+// of nested records
+func (l *Logger) maskFields(rec map[string]any) {
+	for k, v := range rec {
+		switch v := v.(type) {
+		case string:
+			rec[k] = l.secrets.Mask(v)
+		case map[string]any:
+			l.maskFields(v)
+		case []map[string]any:
+			for _, nested := range v {
+				l.maskFields(nested)
+			}
+		}
+	}
+}
+
 // LogCommandStart logs the start of a command execution
 func (l *Logger) LogCommandStart(cmd *executor.Command) {
 	if l == nil || l.file == nil {
@@ -63,15 +199,29 @@ func (l *Logger) LogCommandStart(cmd *executor.Command) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	l.enterGroup(cmd)
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{
+			"event":       "start",
+			"id":          cmd.ID,
+			"name":        cmd.Name,
+			"group":       cmd.Group,
+			"command":     cmd.Raw,
+			"working_dir": cmd.WorkingDir,
+		})
+		return
+	}
+
 	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
 	workingDir := cmd.WorkingDir
 	if workingDir == "" {
 		workingDir = "(default)"
 	}
 
-	entry := fmt.Sprintf("[%s] [CMD-%d] START: %s (WorkingDir: %s)\n",
-		timestamp, cmd.ID, cmd.Raw, workingDir)
-	l.file.WriteString(entry)
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] START: %s (%sWorkingDir: %s)\n",
+		timestamp, l.indent(), cmd.ID, cmd.Raw, nameField(cmd), workingDir)
+	l.write(entry)
 	l.file.Sync()
 }
 
@@ -84,9 +234,14 @@ func (l *Logger) LogCommandOutput(cmdID int, line string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{"event": "output", "id": cmdID, "line": line})
+		return
+	}
+
 	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
-	entry := fmt.Sprintf("[%s] [CMD-%d] OUTPUT: %s\n", timestamp, cmdID, line)
-	l.file.WriteString(entry)
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] OUTPUT: %s\n", timestamp, l.indent(), cmdID, line)
+	l.write(entry)
 	l.file.Sync()
 }
 
@@ -99,18 +254,120 @@ func (l *Logger) LogCommandEnd(cmd *executor.Command) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
 	duration := cmd.Duration()
 
-	entry := fmt.Sprintf("[%s] [CMD-%d] END: exit_code=%d duration=%v status=%s",
-		timestamp, cmd.ID, cmd.ExitCode, duration, cmd.Status)
+	if l.format == FormatJSON {
+		rec := map[string]any{
+			"event":       "end",
+			"id":          cmd.ID,
+			"name":        cmd.Name,
+			"group":       cmd.Group,
+			"status":      cmd.Status.String(),
+			"exit_code":   cmd.ExitCode,
+			"duration_ms": duration.Milliseconds(),
+		}
+		if cmd.Error != nil {
+			rec["error"] = cmd.Error.Error()
+		}
+		l.writeRecord(rec)
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] END: exit_code=%d duration=%v status=%s",
+		timestamp, l.indent(), cmd.ID, cmd.ExitCode, duration, cmd.Status)
+
+	if cmd.Name != "" {
+		entry += fmt.Sprintf(" name=%q", cmd.Name)
+	}
 
 	if cmd.Error != nil {
 		entry += fmt.Sprintf(" error=\"%v\"", cmd.Error)
 	}
 
 	entry += "\n\n"
-	l.file.WriteString(entry)
+	l.write(entry)
+	l.file.Sync()
+}
+
+// LogCommandSuspended logs that the user paused a running command
+func (l *Logger) LogCommandSuspended(cmd *executor.Command) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{
+			"event": "suspend",
+			"id":    cmd.ID,
+		})
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] SUSPENDED\n", timestamp, l.indent(), cmd.ID)
+	l.write(entry)
+	l.file.Sync()
+}
+
+// LogCommandResumed logs that a paused command was resumed and how long it
+// has been paused in total
+func (l *Logger) LogCommandResumed(cmd *executor.Command) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{
+			"event":     "resume",
+			"id":        cmd.ID,
+			"paused_ms": cmd.PausedFor().Milliseconds(),
+		})
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] RESUMED: paused=%v\n", timestamp, l.indent(), cmd.ID, cmd.PausedFor())
+	l.write(entry)
+	l.file.Sync()
+}
+
+// LogCommandCached logs that a step was skipped because a cached result
+// matched, with the cache key and how many output files were restored
+func (l *Logger) LogCommandCached(cmd *executor.Command, key string, restored int) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.enterGroup(cmd)
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{
+			"event":    "cached",
+			"id":       cmd.ID,
+			"name":     cmd.Name,
+			"group":    cmd.Group,
+			"command":  cmd.Raw,
+			"key":      key,
+			"restored": restored,
+		})
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] CACHED: %s (%sKey: %s, Restored: %d files)\n\n",
+		timestamp, l.indent(), cmd.ID, cmd.Raw, nameField(cmd), key[:12], restored)
+	l.write(entry)
 	l.file.Sync()
 }
 
@@ -123,12 +380,399 @@ func (l *Logger) LogCommandSkipped(cmd *executor.Command) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	l.enterGroup(cmd)
+
+	if l.format == FormatJSON {
+		rec := map[string]any{
+			"event":   "skipped",
+			"id":      cmd.ID,
+			"name":    cmd.Name,
+			"group":   cmd.Group,
+			"command": cmd.Raw,
+			"reason":  cmd.SkipReason,
+		}
+		if cmd.Pause != "" {
+			rec["prompt"] = cmd.Pause
+		}
+		l.writeRecord(rec)
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] SKIPPED: %s", timestamp, l.indent(), cmd.ID, cmd.Raw)
+
+	var details []string
+	if cmd.Name != "" {
+		details = append(details, fmt.Sprintf("Name: %s", cmd.Name))
+	}
+	if cmd.Pause != "" {
+		details = append(details, fmt.Sprintf("Prompt: %s", cmd.Pause))
+	}
+	if cmd.SkipReason != "" {
+		details = append(details, fmt.Sprintf("Reason: %s", cmd.SkipReason))
+	}
+	if len(details) > 0 {
+		entry += " (" + strings.Join(details, ", ") + ")"
+	}
+	entry += "\n"
+	l.write(entry)
+	l.file.Sync()
+}
+
+// LogPause logs that the run reached an approval gate and is waiting for an answer
+func (l *Logger) LogPause(cmd *executor.Command) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.enterGroup(cmd)
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{
+			"event":  "pause",
+			"id":     cmd.ID,
+			"name":   cmd.Name,
+			"group":  cmd.Group,
+			"prompt": cmd.Pause,
+		})
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] PAUSE: %s\n", timestamp, l.indent(), cmd.ID, cmd.Pause)
+	l.write(entry)
+	l.file.Sync()
+}
+
+// LogApproval logs the answer to an approval gate and where it came from
+// (the prompt or the --approve flag)
+func (l *Logger) LogApproval(cmd *executor.Command, approved bool, by string) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{
+			"event":       "approval",
+			"id":          cmd.ID,
+			"approved":    approved,
+			"by":          by,
+			"duration_ms": cmd.Duration().Milliseconds(),
+		})
+		return
+	}
+
+	answer := "DECLINED"
+	if approved {
+		answer = "APPROVED"
+	}
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] %s: by=%s waited=%v\n\n", timestamp, l.indent(), cmd.ID, answer, by, cmd.Duration())
+	l.write(entry)
+	l.file.Sync()
+}
+
+// QueueAction is a change made to the pending queue while a run is in progress
+type QueueAction string
+
+const (
+	QueueInsert QueueAction = "insert"
+	QueueDelete QueueAction = "delete"
+	QueueEdit   QueueAction = "edit"
+	QueueMove   QueueAction = "move"
+)
+
+// LogQueueEdit records a change to a pending command. position is the
+// command's 1-based place in the list after the change (0 once deleted) and
+// previous is the command text before an edit.
+func (l *Logger) LogQueueEdit(action QueueAction, cmd *executor.Command, position int, previous string) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.enterGroup(cmd)
+	if action == QueueDelete {
+		// A deleted command never runs, so it does not count toward the group
+		l.group.Commands = slices.DeleteFunc(l.group.Commands, func(c *executor.Command) bool { return c == cmd })
+	}
+
+	if l.format == FormatJSON {
+		rec := map[string]any{
+			"event":    "queue_" + string(action),
+			"id":       cmd.ID,
+			"name":     cmd.Name,
+			"group":    cmd.Group,
+			"command":  cmd.Raw,
+			"position": position,
+		}
+		if action == QueueEdit {
+			rec["previous"] = previous
+		}
+		l.writeRecord(rec)
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	entry := fmt.Sprintf("[%s] %s[CMD-%d] QUEUE %s: %s", timestamp, l.indent(), cmd.ID, strings.ToUpper(string(action)), cmd.Raw)
+
+	var details []string
+	if position > 0 {
+		details = append(details, fmt.Sprintf("Position: %d", position))
+	}
+	if cmd.Name != "" {
+		details = append(details, fmt.Sprintf("Name: %s", cmd.Name))
+	}
+	if cmd.Group != "" {
+		details = append(details, fmt.Sprintf("Group: %s", cmd.Group))
+	}
+	if action == QueueEdit {
+		details = append(details, fmt.Sprintf("Previous: %s", previous))
+	}
+	if len(details) > 0 {
+		entry += " (" + strings.Join(details, ", ") + ")"
+	}
+
+	l.write(entry + "\n")
+	l.file.Sync()
+}
+
+// LogCommands records the steps of a run as it starts, so steps that never
+// start still appear when the log is viewed
+func (l *Logger) LogCommands(commands []*executor.Command) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.format == FormatJSON {
+		list := make([]map[string]any, 0, len(commands))
+		for _, cmd := range commands {
+			list = append(list, map[string]any{
+				"id":      cmd.ID,
+				"name":    cmd.Name,
+				"group":   cmd.Group,
+				"command": cmd.Raw,
+				"prompt":  cmd.Pause,
+			})
+		}
+		l.writeRecord(map[string]any{"event": "run_commands", "commands": list})
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("Commands:\n")
+	for i, cmd := range commands {
+		var details []string
+		if cmd.Name != "" {
+			details = append(details, fmt.Sprintf("Name: %s", cmd.Name))
+		}
+		if cmd.Group != "" {
+			details = append(details, fmt.Sprintf("Group: %s", cmd.Group))
+		}
+		if cmd.Pause != "" {
+			details = append(details, fmt.Sprintf("Prompt: %s", cmd.Pause))
+		}
+		fmt.Fprintf(&b, "  %d. [CMD-%d] %s", i+1, cmd.ID, cmd.Raw)
+		if len(details) > 0 {
+			b.WriteString(" (" + strings.Join(details, ", ") + ")")
+		}
+		b.WriteString("\n")
+	}
+	l.write(b.String() + "\n")
+	l.file.Sync()
+}
+
+// LogCommandList records the final command list of a run whose queue was
+// edited, so the commands that actually ran can be audited
+func (l *Logger) LogCommandList(commands []*executor.Command) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closeGroup()
+
+	if l.format == FormatJSON {
+		list := make([]map[string]any, 0, len(commands))
+		for _, cmd := range commands {
+			list = append(list, map[string]any{
+				"id":      cmd.ID,
+				"name":    cmd.Name,
+				"group":   cmd.Group,
+				"command": cmd.Raw,
+				"status":  cmd.Status.String(),
+			})
+		}
+		l.writeRecord(map[string]any{"event": "final_commands", "commands": list})
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("\nFinal command list (queue edited during the run):\n")
+	for i, cmd := range commands {
+		fmt.Fprintf(&b, "  %d. [CMD-%d] %s: %s\n", i+1, cmd.ID, cmd.Status, cmd.Raw)
+	}
+	l.write(b.String())
+	l.file.Sync()
+}
+
+// LogWatchRun marks the start of another run in watch mode and the file
+// change that triggered it
+func (l *Logger) LogWatchRun(run int, trigger string) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closeGroup()
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{
+			"event":   "watch_run",
+			"run":     run,
+			"trigger": trigger,
+		})
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	l.write(fmt.Sprintf("[%s] ===== RUN %d: %s changed =====\n\n", timestamp, run, trigger))
+	l.file.Sync()
+}
+
+// LogHook logs a hook that ran when cmd finished, or when the run finished if
+// cmd is nil, with the output of a hook command and the error if it failed
+func (l *Logger) LogHook(cmd *executor.Command, success bool, hook hooks.Hook, output string, err error) {
+	if l == nil || l.file == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	on := "on_failure"
+	if success {
+		on = "on_success"
+	}
+
+	if l.format == FormatJSON {
+		rec := map[string]any{
+			"event":  "hook",
+			"on":     on,
+			"hook":   hook.String(),
+			"output": output,
+		}
+		if cmd != nil {
+			rec["id"] = cmd.ID
+		}
+		if err != nil {
+			rec["error"] = err.Error()
+		}
+		l.writeRecord(rec)
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	entry := fmt.Sprintf("[%s] %sHOOK %s: %s", timestamp, l.indent(), on, hook)
+	if cmd != nil {
+		entry = fmt.Sprintf("[%s] %s[CMD-%d] HOOK %s: %s", timestamp, l.indent(), cmd.ID, on, hook)
+	}
+	if err != nil {
+		entry += fmt.Sprintf(" (Failed: %v)", err)
+	}
+	entry += "\n"
+	for _, line := range strings.Split(output, "\n") {
+		if line != "" {
+			entry += l.indent() + "  " + line + "\n"
+		}
+	}
+	l.write(entry + "\n")
+	l.file.Sync()
+}
+
+// enterGroup opens the group section for cmd, closing the previous one if the
+// command belongs to a different group. The caller must hold l.mu.
+func (l *Logger) enterGroup(cmd *executor.Command) {
+	if cmd.Group == l.group.Name && cmd.Group != "" {
+		// A queued command is logged again when it starts
+		if !slices.Contains(l.group.Commands, cmd) {
+			l.group.Commands = append(l.group.Commands, cmd)
+		}
+		return
+	}
+
+	l.closeGroup()
+	if cmd.Group == "" {
+		return
+	}
+	l.group = executor.Group{Name: cmd.Group, Start: cmd.ID, Commands: []*executor.Command{cmd}}
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{"event": "group_start", "group": cmd.Group})
+		return
+	}
+
 	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
-	entry := fmt.Sprintf("[%s] [CMD-%d] SKIPPED: %s\n", timestamp, cmd.ID, cmd.Raw)
-	l.file.WriteString(entry)
+	l.write(fmt.Sprintf("[%s] GROUP START: %s\n", timestamp, cmd.Group))
 	l.file.Sync()
 }
 
+// closeGroup ends the open group section with its aggregate status and total
+// duration. The caller must hold l.mu.
+func (l *Logger) closeGroup() {
+	if l.group.Name == "" {
+		return
+	}
+	g := l.group
+	l.group = executor.Group{}
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{
+			"event":       "group_end",
+			"group":       g.Name,
+			"status":      g.Status().String(),
+			"duration_ms": g.Duration().Milliseconds(),
+		})
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	l.write(fmt.Sprintf("[%s] GROUP END: %s duration=%v status=%s\n\n",
+		timestamp, g.Name, g.Duration(), g.Status()))
+	l.file.Sync()
+}
+
+// indent returns the prefix that nests entries inside an open group section
+func (l *Logger) indent() string {
+	if l.group.Name == "" {
+		return ""
+	}
+	return "  "
+}
+
+// nameField returns the "Name: ..., " prefix for a named command's START entry
+func nameField(cmd *executor.Command) string {
+	if cmd.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf("Name: %s, ", cmd.Name)
+}
+
 // Path returns the path to the log file
 func (l *Logger) Path() string {
 	if l == nil {
@@ -146,11 +790,18 @@ func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	l.closeGroup()
+
+	if l.format == FormatJSON {
+		l.writeRecord(map[string]any{"event": "run_end"})
+		return l.file.Close()
+	}
+
 	// Write footer
 	footer := fmt.Sprintf("\n%s\nCompleted: %s\n",
 		"=======================================================",
 		time.Now().Format("2006-01-02 15:04:05"))
-	l.file.WriteString(footer)
+	l.write(footer)
 	l.file.Sync()
 
 	return l.file.Close()
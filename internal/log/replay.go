@@ -0,0 +1,470 @@
+package log
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+)
+
+// textSeparator is the rule written below the header and above the footer of text logs
+const textSeparator = "======================================================="
+
+// Patterns for reading text logs back
+var (
+	textEntry       = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{3})\] (?:  )?(.*)$`)
+	textStep        = regexp.MustCompile(`(?s)^\[CMD-(\d+)\] ([A-Z]+)(?::? (.*))?$`)
+	textGroupStart  = regexp.MustCompile(`^GROUP START: (.*)$`)
+	textWatchRun    = regexp.MustCompile(`^===== RUN \d+: .* changed =====$`)
+	textStart       = regexp.MustCompile(`(?s)^(.*) \((?:Name: (.*), )?WorkingDir: (.*)\)$`)
+	textEnd         = regexp.MustCompile(`(?s)^exit_code=(-?\d+) duration=(\S+) status=(\S+)(?: name=("(?:[^"\\]|\\.)*"))?(?: error="(.*)")?$`)
+	textSkipped     = regexp.MustCompile(`(?s)^(.*) \((?:Name: (.*?), )?(?:Prompt: (.*?), )?Reason: (.*)\)$`)
+	textCached      = regexp.MustCompile(`(?s)^(.*) \((?:Name: (.*), )?Key: \w+, Restored: \d+ files\)$`)
+	textApproval    = regexp.MustCompile(`^by=(\S+) waited=(\S+)$`)
+	textQueue       = regexp.MustCompile(`(?s)^(INSERT|DELETE|EDIT|MOVE): (.*?)(?: \((?:Position: \d+)?(?:(?:, )?Name: (.*?))?(?:(?:, )?Group: (.*?))?(?:(?:, )?Previous: .*)?\))?$`)
+	textListHeader  = "Commands:"
+	textFinalHeader = "Final command list (queue edited during the run):"
+	textListItem    = regexp.MustCompile(`^  \d+\. \[CMD-\d+\] `)
+	textListEntry   = regexp.MustCompile(`(?s)^  \d+\. \[CMD-(\d+)\] (.*)$`)
+	textListDetails = regexp.MustCompile(`(?s)^(.*?) ?\((?:Name: (.*?))?(?:(?:, )?Group: (.*?))?(?:(?:, )?Prompt: (.*?))?\)$`)
+	textFinalEntry  = regexp.MustCompile(`(?s)^  \d+\. \[CMD-(\d+)\] (\S+): (.*)$`)
+)
+
+// entry is one event of a log, as written in JSONL logs. Text logs are read
+// into the same events.
+type entry struct {
+	Event      string  `json:"event"`
+	ID         int     `json:"id"`
+	Name       string  `json:"name"`
+	Group      string  `json:"group"`
+	Command    string  `json:"command"`
+	WorkingDir string  `json:"working_dir"`
+	Line       string  `json:"line"`
+	Status     string  `json:"status"`
+	ExitCode   int     `json:"exit_code"`
+	DurationMS int64   `json:"duration_ms"`
+	Error      string  `json:"error"`
+	Reason     string  `json:"reason"`
+	Prompt     string  `json:"prompt"`
+	Approved   bool    `json:"approved"`
+	Commands   []entry `json:"commands"` // Command list of the run, or the final one of a run whose queue was edited
+	Time       string  `json:"time"`
+
+	at time.Time
+}
+
+// replay rebuilds the steps of a run from the events of its log
+type replay struct {
+	commands map[int]*executor.Command
+	final    []int     // IDs in the order of the final command list, if the queue was edited
+	last     time.Time // Time of the latest event
+}
+
+// Load reads the log of an earlier run, in either format and optionally
+// gzipped, and returns its steps with their final status, output and
+// duration. A watch mode log gives the steps of its last run.
+func Load(path string) ([]*executor.Command, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	if magic, err := r.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read compressed log: %w", err)
+		}
+		defer zr.Close()
+		r = bufio.NewReader(zr)
+	}
+
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+
+	rp := &replay{commands: make(map[int]*executor.Command)}
+	if first[0] == '{' {
+		err = rp.readJSON(r)
+	} else {
+		err = rp.readText(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	commands := rp.steps()
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("%s has no steps", path)
+	}
+	return commands, nil
+}
+
+// readJSON reads the events of a JSONL log
+func (rp *replay) readJSON(r *bufio.Reader) error {
+	for n := 1; ; n++ {
+		line, err := r.ReadString('\n')
+		if strings.TrimSpace(line) != "" {
+			var e entry
+			if jsonErr := json.Unmarshal([]byte(line), &e); jsonErr != nil {
+				return fmt.Errorf("line %d: %w", n, jsonErr)
+			}
+			e.at, _ = time.Parse(time.RFC3339Nano, e.Time)
+			rp.apply(e)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readText reads the events of a text log. An entry runs until the next
+// timestamped line, as multi-line commands and errors continue on the lines
+// after it.
+func (rp *replay) readText(r *bufio.Reader) error {
+	header, _ := r.ReadString('\n')
+	if !strings.HasPrefix(header, "LazyCommands Execution Log") {
+		return errors.New("not a lazycommands log")
+	}
+
+	var (
+		group   string // Open group section
+		pending []string
+		at      time.Time
+		list    string   // Header of the command list being read, if any
+		item    []string // Lines of the command list entry being read
+	)
+	flush := func() {
+		if pending != nil {
+			rp.applyText(strings.TrimRight(strings.Join(pending, "\n"), "\n"), at, &group)
+			pending = nil
+		}
+		if item != nil {
+			rp.applyListItem(list, strings.TrimRight(strings.Join(item, "\n"), "\n"))
+			item = nil
+		}
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimSuffix(line, "\n")
+
+		switch match := textEntry.FindStringSubmatch(line); {
+		case match != nil:
+			flush()
+			list = ""
+			at, _ = time.ParseInLocation("2006-01-02 15:04:05.000", match[1], time.Local)
+			pending = []string{match[2]}
+		case line == textSeparator:
+			flush()
+			list = ""
+		case line == textListHeader || line == textFinalHeader:
+			flush()
+			list = line
+			if list == textFinalHeader {
+				rp.final = nil
+			}
+		case list != "":
+			// Multi-line commands continue an entry up to the next one
+			if textListItem.MatchString(line) {
+				flush()
+			}
+			if item != nil || textListItem.MatchString(line) {
+				item = append(item, line)
+			}
+		case pending != nil:
+			pending = append(pending, line)
+		}
+
+		if err == io.EOF {
+			flush()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// applyListItem applies one entry of a text log's command list: the run's
+// steps as it started, or the final list of an edited queue
+func (rp *replay) applyListItem(list, text string) {
+	if list == textFinalHeader {
+		if m := textFinalEntry.FindStringSubmatch(text); m != nil {
+			id, _ := strconv.Atoi(m[1])
+			rp.listed(entry{ID: id, Status: m[2], Command: m[3]})
+		}
+		return
+	}
+
+	m := textListEntry.FindStringSubmatch(text)
+	if m == nil {
+		return
+	}
+	id, _ := strconv.Atoi(m[1])
+	e := entry{ID: id, Command: m[2]}
+	if d := textListDetails.FindStringSubmatch(m[2]); d != nil && d[2]+d[3]+d[4] != "" {
+		e.Command, e.Name, e.Group, e.Prompt = d[1], d[2], d[3], d[4]
+	}
+	rp.command(e)
+}
+
+// applyText applies one text log entry. group tracks the open group
+// section, which the step entries inside it belong to.
+func (rp *replay) applyText(text string, at time.Time, group *string) {
+	if m := textGroupStart.FindStringSubmatch(text); m != nil {
+		*group = m[1]
+		return
+	}
+	if strings.HasPrefix(text, "GROUP END: ") {
+		*group = ""
+		return
+	}
+	if textWatchRun.MatchString(text) {
+		*group = ""
+		rp.apply(entry{Event: "watch_run", at: at})
+		return
+	}
+
+	m := textStep.FindStringSubmatch(text)
+	if m == nil {
+		return
+	}
+	id, _ := strconv.Atoi(m[1])
+	e := entry{ID: id, Group: *group, at: at}
+	body := m[3]
+
+	switch m[2] {
+	case "START":
+		e.Event, e.Command = "start", body
+		if d := textStart.FindStringSubmatch(body); d != nil {
+			e.Command, e.Name, e.WorkingDir = d[1], d[2], d[3]
+			if e.WorkingDir == "(default)" {
+				e.WorkingDir = ""
+			}
+		}
+	case "OUTPUT":
+		e.Event, e.Line = "output", body
+	case "END":
+		d := textEnd.FindStringSubmatch(body)
+		if d == nil {
+			return
+		}
+		e.Event = "end"
+		e.ExitCode, _ = strconv.Atoi(d[1])
+		duration, _ := time.ParseDuration(d[2])
+		e.DurationMS = duration.Milliseconds()
+		e.Status = d[3]
+		if d[4] != "" {
+			e.Name, _ = strconv.Unquote(d[4])
+		}
+		e.Error = d[5]
+	case "SKIPPED":
+		e.Event, e.Command = "skipped", body
+		if d := textSkipped.FindStringSubmatch(body); d != nil {
+			e.Command, e.Name, e.Prompt, e.Reason = d[1], d[2], d[3], d[4]
+		}
+	case "CACHED":
+		e.Event, e.Command = "cached", body
+		if d := textCached.FindStringSubmatch(body); d != nil {
+			e.Command, e.Name = d[1], d[2]
+		}
+	case "QUEUE":
+		d := textQueue.FindStringSubmatch(body)
+		if d == nil {
+			return
+		}
+		e.Event = "queue_" + strings.ToLower(d[1])
+		e.Command, e.Name, e.Group = d[2], d[3], d[4]
+	case "PAUSE":
+		e.Event, e.Prompt = "pause", body
+	case "APPROVED", "DECLINED":
+		d := textApproval.FindStringSubmatch(body)
+		if d == nil {
+			return
+		}
+		e.Event, e.Approved = "approval", m[2] == "APPROVED"
+		waited, _ := time.ParseDuration(d[2])
+		e.DurationMS = waited.Milliseconds()
+	default:
+		// Suspends and hooks do not change the final state
+		return
+	}
+	rp.apply(e)
+}
+
+// apply updates the steps with one event
+func (rp *replay) apply(e entry) {
+	if !e.at.IsZero() {
+		rp.last = e.at
+	}
+
+	switch e.Event {
+	case "watch_run":
+		// Each run starts over; the last one is shown
+		rp.commands = make(map[int]*executor.Command)
+		rp.final = nil
+		return
+	case "run_commands":
+		// Steps that never start have no other events
+		for _, listed := range e.Commands {
+			rp.command(listed)
+		}
+		return
+	case "final_commands":
+		rp.final = nil
+		for _, listed := range e.Commands {
+			rp.listed(listed)
+		}
+		return
+	case "queue_insert", "queue_edit":
+		// The final command list has the queue as it ended, if the run got
+		// that far
+		rp.command(e)
+		return
+	case "queue_delete":
+		delete(rp.commands, e.ID)
+		return
+	case "start", "output", "end", "skipped", "cached", "pause", "approval":
+	default:
+		return
+	}
+
+	cmd := rp.command(e)
+	switch e.Event {
+	case "start":
+		cmd.Status = executor.StatusRunning
+		cmd.StartTime = e.at
+		cmd.WorkingDir = e.WorkingDir
+	case "output":
+		if !strings.HasPrefix(e.Line, executor.WorkingDirMarker) {
+			cmd.AppendOutput(e.Line)
+		}
+	case "end":
+		duration := time.Duration(e.DurationMS) * time.Millisecond
+		if cmd.StartTime.IsZero() {
+			cmd.StartTime = e.at.Add(-duration)
+		}
+		cmd.EndTime = cmd.StartTime.Add(duration)
+		cmd.ExitCode = e.ExitCode
+		if e.Error != "" {
+			cmd.Error = errors.New(e.Error)
+		}
+		status, ok := executor.ParseStatus(e.Status)
+		if !ok {
+			status = executor.StatusCompleted
+			if e.ExitCode != 0 || e.Error != "" {
+				status = executor.StatusFailed
+			}
+		}
+		cmd.Status = status
+	case "skipped":
+		cmd.Status = executor.StatusSkipped
+		cmd.SkipReason = e.Reason
+	case "cached":
+		cmd.Status = executor.StatusCached
+		cmd.StartTime = e.at
+		cmd.EndTime = e.at
+	case "pause":
+		cmd.Status = executor.StatusWaiting
+		cmd.StartTime = e.at
+	case "approval":
+		cmd.EndTime = cmd.StartTime.Add(time.Duration(e.DurationMS) * time.Millisecond)
+		cmd.Status = executor.StatusCompleted
+		if !e.Approved {
+			cmd.Status = executor.StatusSkipped
+			cmd.SkipReason = "declined"
+		}
+	}
+}
+
+// command returns the step an event is about, creating it on its first
+// event, and fills in what the event says about it
+func (rp *replay) command(e entry) *executor.Command {
+	cmd, ok := rp.commands[e.ID]
+	if !ok {
+		cmd = executor.NewCommand(e.ID, "")
+		rp.commands[e.ID] = cmd
+	}
+	if e.Command != "" {
+		cmd.Raw = e.Command
+	}
+	if e.Name != "" {
+		cmd.Name = e.Name
+	}
+	if e.Group != "" {
+		cmd.Group = e.Group
+	}
+	if e.Prompt != "" {
+		cmd.Pause = e.Prompt
+	}
+	return cmd
+}
+
+// listed records a step of the final command list. Steps that never ran
+// have no other events, so they take their status from the list.
+func (rp *replay) listed(e entry) {
+	rp.final = append(rp.final, e.ID)
+	if _, ok := rp.commands[e.ID]; ok {
+		return
+	}
+	cmd := rp.command(e)
+	if status, ok := executor.ParseStatus(e.Status); ok {
+		cmd.Status = status
+	}
+}
+
+// steps returns the steps of the final command list, which leaves out steps
+// deleted from the queue, or all steps by ID when the queue was not edited.
+// Steps the log leaves unfinished, because the run was killed, are marked as
+// such.
+func (rp *replay) steps() []*executor.Command {
+	var commands []*executor.Command
+	if rp.final != nil {
+		seen := make(map[int]bool)
+		for _, id := range rp.final {
+			if cmd, ok := rp.commands[id]; ok && !seen[id] {
+				commands = append(commands, cmd)
+				seen[id] = true
+			}
+		}
+	} else {
+		for _, cmd := range rp.commands {
+			commands = append(commands, cmd)
+		}
+		sort.Slice(commands, func(i, j int) bool { return commands[i].ID < commands[j].ID })
+	}
+
+	for _, cmd := range commands {
+		switch cmd.Status {
+		case executor.StatusRunning, executor.StatusPaused:
+			cmd.Status = executor.StatusFailed
+			cmd.EndTime = rp.last
+			cmd.ExitCode = -1
+			cmd.Error = errors.New("the log ends before the step finished")
+		case executor.StatusWaiting:
+			cmd.Status = executor.StatusSkipped
+			cmd.EndTime = rp.last
+			cmd.SkipReason = "the log ends before the gate was answered"
+		case executor.StatusPending:
+			cmd.Status = executor.StatusSkipped
+			cmd.SkipReason = "the log ends before the step ran"
+		}
+	}
+	return commands
+}
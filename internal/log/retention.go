@@ -0,0 +1,288 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// logName matches the files written by NewLogger, capturing the PID of the run
+var logName = regexp.MustCompile(`^lazycommands-\d{4}-\d{2}-\d{2}-\d{6}-(\d+)\.(log|jsonl)(\.gz)?$`)
+
+// Retention limits which logs of earlier runs are kept. Zero values keep
+// everything.
+type Retention struct {
+	MaxFiles int           // Number of logs kept, including the current one
+	MaxAge   time.Duration // Logs older than this are deleted
+	MaxSize  int64         // Oldest logs are deleted until the total is under this many bytes
+}
+
+// IsZero reports whether the retention keeps every log
+func (r Retention) IsZero() bool {
+	return r == Retention{}
+}
+
+// retentionJSON is how retention is written in the config file
+type retentionJSON struct {
+	MaxFiles int    `json:"max_files,omitempty"`
+	MaxAge   string `json:"max_age,omitempty"`
+	MaxSize  string `json:"max_size,omitempty"`
+}
+
+// MarshalJSON writes the age and size limits in the units they are read in
+func (r Retention) MarshalJSON() ([]byte, error) {
+	raw := retentionJSON{MaxFiles: r.MaxFiles}
+	if r.MaxAge > 0 {
+		raw.MaxAge = formatAge(r.MaxAge)
+	}
+	if r.MaxSize > 0 {
+		raw.MaxSize = formatSize(r.MaxSize)
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON reads limits such as {"max_files": 50, "max_age": "14d", "max_size": "500MB"}
+func (r *Retention) UnmarshalJSON(data []byte) error {
+	var raw retentionJSON
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return errors.New(`must be an object with "max_files", "max_age" and "max_size"`)
+	}
+	if raw.MaxFiles < 0 {
+		return errors.New("max_files: must not be negative")
+	}
+
+	retention := Retention{MaxFiles: raw.MaxFiles}
+	if raw.MaxAge != "" {
+		age, err := parseAge(raw.MaxAge)
+		if err != nil {
+			return fmt.Errorf("max_age: %w", err)
+		}
+		retention.MaxAge = age
+	}
+	if raw.MaxSize != "" {
+		size, err := parseSize(raw.MaxSize)
+		if err != nil {
+			return fmt.Errorf("max_size: %w", err)
+		}
+		retention.MaxSize = size
+	}
+	*r = retention
+	return nil
+}
+
+// parseAge reads a duration, also accepting whole days such as "7d"
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid age %q (use days such as 7d, or a duration such as 12h)", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q (use days such as 7d, or a duration such as 12h)", s)
+	}
+	return d, nil
+}
+
+// formatAge writes whole days as "7d" and other durations as Go durations
+func formatAge(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// sizeUnits are the suffixes accepted by parseSize, largest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize reads a size such as "500MB"
+func parseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(upper, unit.suffix); ok {
+			n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+			if err != nil || n <= 0 {
+				break
+			}
+			return n * unit.bytes, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid size %q (use a number with B, KB, MB or GB)", s)
+}
+
+// formatSize writes a size in the largest unit that divides it
+func formatSize(n int64) string {
+	for _, unit := range sizeUnits {
+		if n%unit.bytes == 0 {
+			return fmt.Sprintf("%d%s", n/unit.bytes, unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// ProjectDir returns the subdirectory of dir for the project containing cwd:
+// the enclosing git repository, or cwd itself. Its name is the project's
+// base name with a short hash of its path, so projects with the same name
+// do not share logs.
+func ProjectDir(dir, cwd string) string {
+	root := cwd
+	for d := cwd; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			root = d
+			break
+		}
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	sum := sha256.Sum256([]byte(root))
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, filepath.Base(root))
+	return filepath.Join(dir, name+"-"+hex.EncodeToString(sum[:4]))
+}
+
+// logFile is a log of an earlier run found in the log directory
+type logFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// prune compresses and deletes the logs of earlier runs in dir according to
+// the retention. current is never touched, and neither are the logs of runs
+// that are still going. Errors are ignored: a log that cannot be removed is
+// tried again next time.
+func prune(dir, current string, retention Retention, compress bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var logs []logFile
+	for _, entry := range entries {
+		match := logName.FindStringSubmatch(entry.Name())
+		path := filepath.Join(dir, entry.Name())
+		if match == nil || entry.IsDir() || path == current {
+			continue
+		}
+		if pid, err := strconv.Atoi(match[1]); err == nil && running(pid) {
+			continue
+		}
+
+		if compress && match[3] == "" {
+			if compressed, err := gzipFile(path); err == nil {
+				path = compressed
+			}
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		logs = append(logs, logFile{path: path, size: info.Size(), modTime: info.ModTime()})
+	}
+
+	// Newest first, so the logs past a limit are the oldest
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].modTime.After(logs[j].modTime)
+	})
+
+	var total int64
+	if info, err := os.Stat(current); err == nil {
+		total = info.Size()
+	}
+	now := time.Now()
+	for i, lf := range logs {
+		total += lf.size
+		switch {
+		case retention.MaxFiles > 0 && i+1 >= retention.MaxFiles,
+			retention.MaxAge > 0 && now.Sub(lf.modTime) > retention.MaxAge,
+			retention.MaxSize > 0 && total > retention.MaxSize:
+			os.Remove(lf.path)
+		}
+	}
+}
+
+// gzipFile compresses path to path.gz, keeping its modification time, and
+// removes the original
+func gzipFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	target := path + ".gz"
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".compress-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = info.ModTime()
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", err
+	}
+	os.Chtimes(target, info.ModTime(), info.ModTime())
+	os.Remove(path)
+	return target, nil
+}
+
+// running reports whether a process with the given PID exists
+func running(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, os.ErrPermission)
+}
@@ -0,0 +1,443 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Entry is a single command parsed from a command list
+type Entry struct {
+	Line int    // Line number where the command starts (1-based)
+	Name string // Optional display name from a "name: command" prefix
+	Raw  string // Command text, possibly spanning multiple lines
+}
+
+// SyntaxError describes a problem in the command list and where it occurred
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+// Error implements the error interface
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// block is an open compound command (if, for, case, {, ( ...) awaiting its closer
+type block struct {
+	opener string
+	closer string
+	line   int
+}
+
+// heredoc is a here-document whose body has not been terminated yet
+type heredoc struct {
+	delim     string
+	stripTabs bool // <<- strips leading tabs from body lines and the delimiter
+	line      int
+}
+
+// openers maps keywords that start a compound command to their closing keyword
+var openers = map[string]string{
+	"if":     "fi",
+	"for":    "done",
+	"while":  "done",
+	"until":  "done",
+	"select": "done",
+	"case":   "esac",
+	"{":      "}",
+}
+
+// closers is the set of keywords that terminate a compound command
+var closers = map[string]bool{
+	"fi":   true,
+	"done": true,
+	"esac": true,
+	"}":    true,
+}
+
+// state tracks the lexer across the physical lines of a single command
+type state struct {
+	lines      []string
+	startLine  int
+	name       string
+	blocks     []block
+	heredocs   []heredoc
+	quote      byte // Open quote character, or 0
+	ansiC      bool // The open quote is $'...', where backslash escapes work
+	quoteLine  int
+	continued  bool // Line ended with a backslash
+	trailingOp bool // Line ended with &&, || or |
+	cmdPos     bool // Next word is in command position (keywords are recognised)
+}
+
+// Parse reads a command list, one command per line. Blank lines and lines
+// starting with # are ignored, trailing backslashes join lines, and multi-line
+// constructs (if/for/while/case blocks, braces, subshells, quoted strings and
+// here-documents) are kept together as a single command. A command may be
+// given a display name with a "name: command" or "\"Some name\": command" prefix.
+func Parse(r io.Reader) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var st *state
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if st == nil {
+			trimmed := strings.TrimSpace(line)
+			// Skip empty lines and comments between commands
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			st = &state{startLine: lineNo, cmdPos: true}
+			st.name, line = splitName(strings.TrimLeft(line, " \t"))
+		}
+
+		st.lines = append(st.lines, line)
+
+		// Inside a here-document body, only look for the terminator
+		if len(st.heredocs) > 0 {
+			hd := st.heredocs[0]
+			candidate := line
+			if hd.stripTabs {
+				candidate = strings.TrimLeft(candidate, "\t")
+			}
+			if candidate == hd.delim {
+				st.heredocs = st.heredocs[1:]
+			}
+		} else if err := st.scan(line, lineNo); err != nil {
+			return nil, err
+		}
+
+		if st.complete() {
+			entries = append(entries, Entry{
+				Line: st.startLine,
+				Name: st.name,
+				Raw:  strings.TrimSpace(strings.Join(st.lines, "\n")),
+			})
+			st = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read commands: %w", err)
+	}
+
+	if st != nil {
+		return nil, st.eofError(lineNo)
+	}
+
+	return entries, nil
+}
+
+// complete reports whether the accumulated lines form a whole command
+func (st *state) complete() bool {
+	return !st.continued &&
+		!st.trailingOp &&
+		st.quote == 0 &&
+		len(st.blocks) == 0 &&
+		len(st.heredocs) == 0
+}
+
+// eofError explains why the last command is incomplete at end of input
+func (st *state) eofError(lastLine int) error {
+	if len(st.heredocs) > 0 {
+		hd := st.heredocs[0]
+		return &SyntaxError{Line: hd.line, Msg: fmt.Sprintf("here-document is missing its terminator %q", hd.delim)}
+	}
+	if st.quote != 0 {
+		return &SyntaxError{Line: st.quoteLine, Msg: fmt.Sprintf("unterminated %c quote", st.quote)}
+	}
+	if len(st.blocks) > 0 {
+		b := st.blocks[len(st.blocks)-1]
+		return &SyntaxError{Line: b.line, Msg: fmt.Sprintf("`%s` is never closed (missing `%s`)", b.opener, b.closer)}
+	}
+	if st.continued {
+		return &SyntaxError{Line: lastLine, Msg: "line continuation at end of input"}
+	}
+	return &SyntaxError{Line: lastLine, Msg: "command ends with an operator and has no right-hand side"}
+}
+
+// scan lexes one physical line, updating quote, block and heredoc state
+func (st *state) scan(s string, lineNo int) error {
+	st.continued = false
+	i := 0
+
+	// A line that only continues an open quote never ends in an operator
+	if st.quote != 0 {
+		st.trailingOp = false
+	}
+
+	for i < len(s) {
+		c := s[i]
+
+		// Inside quotes, only look for the closing quote
+		if st.quote != 0 {
+			if c == '\\' && (st.quote != '\'' || st.ansiC) {
+				i += 2
+				continue
+			}
+			if c == st.quote {
+				st.quote = 0
+				st.ansiC = false
+			}
+			i++
+			continue
+		}
+
+		switch {
+		case c == '\\':
+			if i == len(s)-1 {
+				st.continued = true
+				return nil
+			}
+			st.trailingOp = false
+			st.cmdPos = false
+			i += 2
+
+		case c == '\'' || c == '"' || c == '`':
+			st.quote = c
+			st.quoteLine = lineNo
+			st.trailingOp = false
+			st.cmdPos = false
+			i++
+
+		case isANSICQuote(s, i):
+			st.quote = '\''
+			st.ansiC = true
+			st.quoteLine = lineNo
+			st.trailingOp = false
+			st.cmdPos = false
+			i += 2
+
+		case c == '#' && (i == 0 || isSeparator(s[i-1])):
+			// Comment runs to end of line
+			i = len(s)
+
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == ';':
+			i++
+			if i < len(s) && (s[i] == ';' || s[i] == '&') {
+				i++
+			}
+			st.trailingOp = false
+			st.cmdPos = true
+
+		case c == '&' || c == '|':
+			j := i + 1
+			for j < len(s) && (s[j] == '&' || s[j] == '|') {
+				j++
+			}
+			op := s[i:j]
+			st.trailingOp = op == "&&" || op == "||" || op == "|"
+			st.cmdPos = true
+			i = j
+
+		case c == '(' || (c == '$' && i+1 < len(s) && s[i+1] == '('):
+			if c == '$' {
+				i++
+			}
+			st.blocks = append(st.blocks, block{opener: "(", closer: ")", line: lineNo})
+			st.trailingOp = false
+			st.cmdPos = true
+			i++
+
+		case c == ')':
+			// Unmatched ) is a case pattern terminator, not a subshell close
+			if n := len(st.blocks); n > 0 && st.blocks[n-1].opener == "(" {
+				st.blocks = st.blocks[:n-1]
+			}
+			st.trailingOp = false
+			st.cmdPos = true
+			i++
+
+		case c == '<' && strings.HasPrefix(s[i:], "<<<"):
+			// Here-string, not a here-document
+			st.trailingOp = false
+			i += 3
+
+		case c == '<' && strings.HasPrefix(s[i:], "<<"):
+			i += 2
+			stripTabs := false
+			if i < len(s) && s[i] == '-' {
+				stripTabs = true
+				i++
+			}
+			delim, next := readHeredocDelim(s, i)
+			if delim == "" {
+				return &SyntaxError{Line: lineNo, Msg: "here-document is missing a delimiter"}
+			}
+			st.heredocs = append(st.heredocs, heredoc{delim: delim, stripTabs: stripTabs, line: lineNo})
+			st.trailingOp = false
+			i = next
+
+		default:
+			j := i
+			for j < len(s) && !isWordEnd(s[j]) && !isANSICQuote(s, j) {
+				j++
+			}
+			if j == i {
+				// Lone redirection or other operator character
+				st.trailingOp = false
+				i++
+				continue
+			}
+			if err := st.word(s[i:j], lineNo); err != nil {
+				return err
+			}
+			st.trailingOp = false
+			i = j
+		}
+	}
+
+	// A newline outside of quotes starts a new command
+	if st.quote == 0 {
+		st.cmdPos = true
+	}
+
+	return nil
+}
+
+// word handles a bare word, tracking compound command keywords
+func (st *state) word(w string, lineNo int) error {
+	if !st.cmdPos {
+		return nil
+	}
+
+	if closer, ok := openers[w]; ok {
+		st.blocks = append(st.blocks, block{opener: w, closer: closer, line: lineNo})
+		// The condition of if/while/until and the body of { are commands;
+		// for/select/case are followed by a name or word
+		st.cmdPos = w == "if" || w == "while" || w == "until" || w == "{"
+		return nil
+	}
+
+	if closers[w] {
+		n := len(st.blocks)
+		if n == 0 || st.blocks[n-1].closer != w {
+			if n > 0 {
+				b := st.blocks[n-1]
+				return &SyntaxError{Line: lineNo, Msg: fmt.Sprintf("unexpected `%s` (expected `%s` to close `%s` from line %d)", w, b.closer, b.opener, b.line)}
+			}
+			return &SyntaxError{Line: lineNo, Msg: fmt.Sprintf("unexpected `%s`", w)}
+		}
+		st.blocks = st.blocks[:n-1]
+		st.cmdPos = false
+		return nil
+	}
+
+	switch w {
+	case "then", "do", "else", "elif", "!", "time":
+		// Pipeline prefixes like ! and time leave the next word a command
+		st.cmdPos = true
+	default:
+		// Variable assignments may precede the command name
+		st.cmdPos = strings.Contains(w, "=") && !strings.HasPrefix(w, "=")
+	}
+
+	return nil
+}
+
+// splitName splits an optional "name: " prefix from the first line of a
+// command. Unquoted names are limited to letters, digits, '.', '_' and '-' so
+// that ordinary commands are never mistaken for names; quoted names may
+// contain anything except the quote character.
+func splitName(line string) (string, string) {
+	var name string
+	var rest string
+
+	if line != "" && (line[0] == '"' || line[0] == '\'') {
+		end := strings.IndexByte(line[1:], line[0])
+		if end < 0 {
+			return "", line
+		}
+		name = line[1 : end+1]
+		rest = line[end+2:]
+	} else {
+		i := 0
+		for i < len(line) && isNameChar(line[i]) {
+			i++
+		}
+		name = line[:i]
+		rest = line[i:]
+	}
+
+	// The name must be followed by a colon, whitespace and a command
+	if !strings.HasPrefix(rest, ":") || len(rest) < 2 || (rest[1] != ' ' && rest[1] != '\t') {
+		return "", line
+	}
+	command := strings.TrimLeft(rest[1:], " \t")
+	name = strings.TrimSpace(name)
+	if name == "" || command == "" {
+		return "", line
+	}
+
+	return name, command
+}
+
+// isNameChar reports whether c may appear in an unquoted step name
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		c == '.' || c == '_' || c == '-'
+}
+
+// readHeredocDelim reads a here-document delimiter starting at i, removing
+// any quoting. It returns the delimiter and the index just past it.
+func readHeredocDelim(s string, i int) (string, int) {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+
+	var b strings.Builder
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return "", len(s)
+			}
+			b.WriteString(s[i+1 : i+1+end])
+			i += end + 2
+		case c == '\\' && i+1 < len(s):
+			b.WriteByte(s[i+1])
+			i += 2
+		case isWordEnd(c):
+			return b.String(), i
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), i
+}
+
+// isANSICQuote reports whether an ANSI-C $'...' quote starts at i
+func isANSICQuote(s string, i int) bool {
+	return strings.HasPrefix(s[i:], "$'")
+}
+
+// isSeparator reports whether c ends the preceding word for comment detection
+func isSeparator(c byte) bool {
+	return c == ' ' || c == '\t' || c == ';' || c == '&' || c == '|' || c == '(' || c == ')'
+}
+
+// isWordEnd reports whether c terminates an unquoted word
+func isWordEnd(c byte) bool {
+	switch c {
+	case ' ', '\t', ';', '&', '|', '(', ')', '<', '>', '\'', '"', '`', '\\':
+		return true
+	}
+	return false
+}
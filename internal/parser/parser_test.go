@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Entry
+	}{
+		{
+			name:  "one command per line",
+			input: "echo one\necho two\n",
+			want:  []Entry{{Line: 1, Raw: "echo one"}, {Line: 2, Raw: "echo two"}},
+		},
+		{
+			name:  "blank lines and comments",
+			input: "\n# setup\n  # indented comment\necho one\n\n",
+			want:  []Entry{{Line: 4, Raw: "echo one"}},
+		},
+		{
+			name:  "line continuation",
+			input: "docker run \\\n  --rm \\\n  alpine\necho next",
+			want:  []Entry{{Line: 1, Raw: "docker run \\\n  --rm \\\n  alpine"}, {Line: 4, Raw: "echo next"}},
+		},
+		{
+			name:  "trailing operator",
+			input: "make build &&\n  make test\n",
+			want:  []Entry{{Line: 1, Raw: "make build &&\n  make test"}},
+		},
+		{
+			name:  "if block",
+			input: "if true; then\n  echo yes\nfi\necho after",
+			want:  []Entry{{Line: 1, Raw: "if true; then\n  echo yes\nfi"}, {Line: 4, Raw: "echo after"}},
+		},
+		{
+			name:  "multi-line quote",
+			input: "echo 'one\ntwo'\n",
+			want:  []Entry{{Line: 1, Raw: "echo 'one\ntwo'"}},
+		},
+		{
+			name:  "ANSI-C quote with escaped quote",
+			input: "echo $'it\\'s'\necho it$'\\'s\\n'\n",
+			want:  []Entry{{Line: 1, Raw: "echo $'it\\'s'"}, {Line: 2, Raw: "echo it$'\\'s\\n'"}},
+		},
+		{
+			name:  "time and ! before a block",
+			input: "time if true; then echo; fi\n! while false; do :; done\necho after",
+			want: []Entry{
+				{Line: 1, Raw: "time if true; then echo; fi"},
+				{Line: 2, Raw: "! while false; do :; done"},
+				{Line: 3, Raw: "echo after"},
+			},
+		},
+		{
+			name:  "heredoc",
+			input: "cat <<EOF\nif this is not a block\nEOF\necho after",
+			want:  []Entry{{Line: 1, Raw: "cat <<EOF\nif this is not a block\nEOF"}, {Line: 4, Raw: "echo after"}},
+		},
+		{
+			name:  "heredoc terminator must stand alone",
+			input: "cat <<EOF\n EOF\nEOF not yet\nEOF\n",
+			want:  []Entry{{Line: 1, Raw: "cat <<EOF\n EOF\nEOF not yet\nEOF"}},
+		},
+		{
+			name:  "quoted heredoc delimiter",
+			input: "cat <<'END'\n$HOME\nEND\n",
+			want:  []Entry{{Line: 1, Raw: "cat <<'END'\n$HOME\nEND"}},
+		},
+		{
+			name:  "heredoc with stripped tabs",
+			input: "cat <<-EOF\n\tindented\n\tEOF\necho after",
+			want:  []Entry{{Line: 1, Raw: "cat <<-EOF\n\tindented\n\tEOF"}, {Line: 4, Raw: "echo after"}},
+		},
+		{
+			name:  "named steps",
+			input: "deps: npm ci\n\"Build image\": docker build .\n",
+			want:  []Entry{{Line: 1, Name: "deps", Raw: "npm ci"}, {Line: 2, Name: "Build image", Raw: "docker build ."}},
+		},
+		{
+			name:  "colon inside a command",
+			input: "echo a:b\n",
+			want:  []Entry{{Line: 1, Raw: "echo a:b"}},
+		},
+		{
+			name:  "carriage returns",
+			input: "echo one\r\necho two\r\n",
+			want:  []Entry{{Line: 1, Raw: "echo one"}, {Line: 2, Raw: "echo two"}},
+		},
+		{
+			name:  "empty input",
+			input: "",
+			want:  []Entry{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+		msg   string
+	}{
+		{"continuation at end of input", "echo one\necho two \\", 2, "line continuation at end of input"},
+		{"continuation before blank end", "echo one \\\n", 1, "line continuation at end of input"},
+		{"missing heredoc terminator", "echo one\ncat <<EOF\nbody\n", 2, `here-document is missing its terminator "EOF"`},
+		{"indented terminator without <<-", "cat <<EOF\nbody\n\tEOF\n", 1, `here-document is missing its terminator "EOF"`},
+		{"unterminated quote", "echo one\necho 'two\nthree", 2, "unterminated ' quote"},
+		{"unclosed if", "echo one\n\nif true; then\n  echo yes\n", 3, "`if` is never closed (missing `fi`)"},
+		{"dangling operator", "make build &&", 1, "command ends with an operator and has no right-hand side"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse error = %v, want a SyntaxError", err)
+			}
+			if syntaxErr.Line != tt.line || syntaxErr.Msg != tt.msg {
+				t.Errorf("Parse error = line %d: %s, want line %d: %s", syntaxErr.Line, syntaxErr.Msg, tt.line, tt.msg)
+			}
+		})
+	}
+}
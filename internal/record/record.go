@@ -0,0 +1,236 @@
+// Package record writes asciinema recordings of the output of a run
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/ui"
+)
+
+// Recorder writes the output of the steps as asciicast v2 recordings, either
+// one for the whole run or one per step. It receives the output line by line
+// as the steps stream it, so each line plays back when it was printed. It
+// implements executor.Logger.
+type Recorder struct {
+	mu      sync.Mutex
+	path    string
+	perStep bool
+	width   int
+	height  int
+	run     *cast         // Recording of the whole run, unless perStep
+	steps   map[int]*cast // Recordings of the running steps by command ID, if perStep
+	files   []string      // Recordings created so far
+	err     error         // First failed write
+}
+
+// cast is an open recording
+type cast struct {
+	file  *os.File
+	start time.Time
+}
+
+// header is the first line of an asciicast v2 file
+type header struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+// New creates a Recorder writing to path for a terminal of the given size.
+// With perStep, each step gets its own file, numbered in the order the steps
+// run: run.cast becomes run-01.cast, run-02.cast and so on.
+func New(path string, perStep bool, width, height int) (*Recorder, error) {
+	r := &Recorder{
+		path:    path,
+		perStep: perStep,
+		width:   width,
+		height:  height,
+		steps:   make(map[int]*cast),
+	}
+	if !perStep {
+		c, err := createCast(path, "lazycommands", width, height)
+		if err != nil {
+			return nil, err
+		}
+		r.run = c
+		r.files = append(r.files, path)
+	}
+	return r, nil
+}
+
+// createCast creates a recording and writes its header
+func createCast(path, title string, width, height int) (*cast, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	c := &cast{file: file, start: time.Now()}
+	err = encode(file, header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: c.start.Unix(),
+		Title:     title,
+	})
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write recording: %w", err)
+	}
+	return c, nil
+}
+
+// encode writes v as a line of JSON, leaving the <, > and & common in shell
+// commands unescaped
+func encode(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// stepPath returns the file of the nth step's recording
+func stepPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%02d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// LogCommandStart starts the step's recording, or its section of the run's
+func (r *Recorder) LogCommandStart(cmd *executor.Command) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.run
+	if r.perStep {
+		path := stepPath(r.path, len(r.files)+1)
+		var err error
+		c, err = createCast(path, label(cmd), r.width, r.height)
+		if err != nil {
+			r.fail(err)
+			return
+		}
+		r.steps[cmd.ID] = c
+		r.files = append(r.files, path)
+	}
+	r.write(c, fmt.Sprintf("%s %s\r\n", ui.StatusIcon(executor.StatusRunning), label(cmd)))
+}
+
+// LogCommandOutput records a line of output at the time it was printed
+func (r *Recorder) LogCommandOutput(cmdID int, line string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.write(r.cast(cmdID), line+"\r\n")
+}
+
+// LogCommandEnd records the step's result, closing its recording if it has
+// its own
+func (r *Recorder) LogCommandEnd(cmd *executor.Command) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := fmt.Sprintf("%s %s (%s", ui.StatusIcon(cmd.Status), label(cmd), ui.FormatDuration(cmd))
+	if cmd.Status != executor.StatusCompleted {
+		result += fmt.Sprintf(", exit code %d", cmd.ExitCode)
+	}
+	c := r.cast(cmd.ID)
+	r.write(c, result+")\r\n\r\n")
+
+	if r.perStep && c != nil {
+		if err := c.file.Close(); err != nil {
+			r.fail(fmt.Errorf("failed to write recording: %w", err))
+		}
+		delete(r.steps, cmd.ID)
+	}
+}
+
+// cast returns the recording a step's output goes to. The caller must hold r.mu.
+func (r *Recorder) cast(cmdID int) *cast {
+	if r.perStep {
+		return r.steps[cmdID]
+	}
+	return r.run
+}
+
+// write appends an output event to c. The caller must hold r.mu.
+func (r *Recorder) write(c *cast, text string) {
+	if c == nil {
+		return
+	}
+
+	// Microseconds are as precise as players use
+	elapsed := math.Round(time.Since(c.start).Seconds()*1e6) / 1e6
+	if err := encode(c.file, []any{elapsed, "o", text}); err != nil {
+		r.fail(fmt.Errorf("failed to write recording: %w", err))
+	}
+}
+
+// fail keeps the first error for Close to report. The caller must hold r.mu.
+func (r *Recorder) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Files returns the recordings created so far
+func (r *Recorder) Files() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.files...)
+}
+
+// Close closes the recordings and reports the first error writing them
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.run != nil {
+		if err := r.run.file.Close(); err != nil {
+			r.fail(fmt.Errorf("failed to write recording: %w", err))
+		}
+		r.run = nil
+	}
+	// Steps still running when the program exits
+	for id, c := range r.steps {
+		c.file.Close()
+		delete(r.steps, id)
+	}
+	return r.err
+}
+
+// label names a step in the recording, prefixed by its group
+func label(cmd *executor.Command) string {
+	if cmd.Group != "" {
+		return cmd.Group + " › " + cmd.Label()
+	}
+	return cmd.Label()
+}
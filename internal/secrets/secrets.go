@@ -0,0 +1,104 @@
+// Package secrets masks secret values in command output, logs and reports
+package secrets
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces each secret
+const Placeholder = "***"
+
+// minLength is the shortest value masked; shorter values would hide ordinary
+// text wherever they happen to appear
+const minLength = 4
+
+// Masker holds the registered secrets. Values can be added while commands
+// run, such as the output of a secret step. A nil Masker masks nothing.
+type Masker struct {
+	mu       sync.RWMutex
+	values   []string // Longest first, so a secret containing another is masked whole
+	patterns []*regexp.Regexp
+}
+
+// New returns a Masker with no secrets
+func New() *Masker {
+	return &Masker{}
+}
+
+// AddValue registers a secret value. Output is masked line by line, so each
+// line of a multi-line value is registered on its own. Lines shorter than four
+// characters are ignored, as are surrounding spaces.
+func (m *Masker) AddValue(value string) {
+	if m == nil {
+		return
+	}
+	for _, line := range strings.Split(value, "\n") {
+		m.addLine(strings.TrimSpace(line))
+	}
+}
+
+// addLine registers a single-line secret
+func (m *Masker) addLine(value string) {
+	if len(value) < minLength {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range m.values {
+		if v == value {
+			return
+		}
+	}
+	m.values = append(m.values, value)
+	sort.SliceStable(m.values, func(i, j int) bool {
+		return len(m.values[i]) > len(m.values[j])
+	})
+}
+
+// AddPattern registers a regular expression whose matches are secrets
+func (m *Masker) AddPattern(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid secret pattern %q: %w", pattern, err)
+	}
+	if re.MatchString("") {
+		return fmt.Errorf("secret pattern %q matches empty text", pattern)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.patterns = append(m.patterns, re)
+	return nil
+}
+
+// Empty reports whether no secrets are registered
+func (m *Masker) Empty() bool {
+	if m == nil {
+		return true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.values) == 0 && len(m.patterns) == 0
+}
+
+// Mask replaces every secret in s with ***
+func (m *Masker) Mask(s string) string {
+	if m == nil || s == "" {
+		return s
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, v := range m.values {
+		s = strings.ReplaceAll(s, v, Placeholder)
+	}
+	for _, re := range m.patterns {
+		s = re.ReplaceAllString(s, Placeholder)
+	}
+	return s
+}
@@ -1,89 +1,136 @@
 package ui
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// defaultWidth is used before the terminal has reported its size
+	defaultWidth = 80
+
+	// durationColumnWidth and exitColumnWidth are the widths of the right-aligned columns
+	durationColumnWidth = 8
+	exitColumnWidth     = 9
+
+	// minLabelWidth is the narrowest the command label may get before columns are dropped
+	minLabelWidth = 20
 )
 
-// StatusIcon returns the icon for a given command status
+// StatusIcon returns the icon for a given command status from the current icon set
 func StatusIcon(status executor.CommandStatus) string {
-	switch status {
-	case executor.StatusPending:
-		return "⏳"
-	case executor.StatusRunning:
-		return "▶️ "
-	case executor.StatusCompleted:
-		return "✔"
-	case executor.StatusFailed:
-		return "x"
-	case executor.StatusSkipped:
-		return "⊘ "
-	default:
-		return "  "
+	if icon, ok := icons[status]; ok {
+		return icon
 	}
+	return "  "
 }
 
-// FormatCommandLine formats a command line with its status icon and styling
-func FormatCommandLine(cmd *executor.Command, isSelected bool) string {
-	icon := StatusIcon(cmd.Status)
-	cmdText := cmd.Raw
+// FormatCommandLine formats a command line with its status icon and styling,
+// fitted to the given terminal width
+func FormatCommandLine(cmd *executor.Command, isSelected bool, width int) string {
+	return FormatCommandLineWithSpinner(cmd, isSelected, "", width)
+}
 
-	// Truncate long commands
-	maxLen := 40
-	if len(cmdText) > maxLen {
-		cmdText = cmdText[:maxLen-3] + "..."
+// FormatCommandLineWithSpinner formats a command line with spinner for running commands.
+// The label is truncated by display width so that the duration and exit code
+// columns line up on the right edge of the terminal.
+func FormatCommandLineWithSpinner(cmd *executor.Command, isSelected bool, spinnerView string, width int) string {
+	// Use spinner for running commands, otherwise use status icon
+	var icon string
+	if cmd.Status == executor.StatusRunning && spinnerView != "" {
+		icon = spinnerView
+	} else {
+		icon = StatusIcon(cmd.Status)
 	}
 
-	line := icon + " " + cmdText
+	if width <= 0 {
+		width = defaultWidth
+	}
 
-	// Apply styling based on status
-	switch cmd.Status {
-	case executor.StatusRunning:
-		line = RunningStyle.Render(line)
-	case executor.StatusCompleted:
-		line = SuccessStyle.Render(line)
-	case executor.StatusFailed:
-		line = ErrorStyle.Render(line)
-	case executor.StatusSkipped:
-		line = SkippedStyle.Render(line)
-	case executor.StatusPending:
-		line = PendingStyle.Render(line)
+	// Leave room for the "> " selection marker and the icon
+	left := icon + " "
+	available := width - 2 - ansi.StringWidth(left)
+
+	// Drop the right-hand columns when the terminal is too narrow for them
+	columns := ""
+	switch {
+	case available-durationColumnWidth-exitColumnWidth >= minLabelWidth:
+		columns = padLeft(FormatDuration(cmd), durationColumnWidth) + padLeft(exitColumn(cmd), exitColumnWidth)
+	case available-durationColumnWidth >= minLabelWidth:
+		columns = padLeft(FormatDuration(cmd), durationColumnWidth)
 	}
 
-	// Highlight if selected
-	if isSelected {
-		line = SelectedStyle.Render("> " + line)
-	} else {
-		line = "  " + line
+	labelWidth := available - ansi.StringWidth(columns)
+	if labelWidth < 1 {
+		labelWidth = 1
 	}
 
-	return line
+	text := cmd.Label()
+	if cmd.Status == executor.StatusSkipped && cmd.SkipReason != "" {
+		text += " (" + cmd.SkipReason + ")"
+	}
+	label := ansi.Truncate(text, labelWidth, "…")
+	if columns != "" {
+		label += strings.Repeat(" ", labelWidth-ansi.StringWidth(label))
+	}
+
+	return styleLine(left+label+columns, cmd.Status, isSelected)
 }
 
-// FormatCommandLineWithSpinner formats a command line with spinner for running commands
-func FormatCommandLineWithSpinner(cmd *executor.Command, isSelected bool, spinnerView string) string {
-	// Use spinner for running commands, otherwise use status icon
-	var icon string
-	if cmd.Status == executor.StatusRunning && spinnerView != "" {
+// FormatGroupLine formats a group header with a fold marker, the aggregate
+// status icon, the total duration and how many steps have finished
+func FormatGroupLine(g executor.Group, collapsed, isSelected bool, spinnerView string, width int) string {
+	status := g.Status()
+	icon := StatusIcon(status)
+	if status == executor.StatusRunning && spinnerView != "" {
 		icon = spinnerView
-	} else {
-		icon = StatusIcon(cmd.Status)
 	}
 
-	cmdText := cmd.Raw
+	if width <= 0 {
+		width = defaultWidth
+	}
+
+	fold := folds.expanded
+	if collapsed {
+		fold = folds.collapsed
+	}
+	left := fold + icon + " "
+	available := width - 2 - ansi.StringWidth(left)
+
+	duration := ""
+	if g.Started() {
+		duration = formatElapsed(g.Duration())
+	}
+	progress := fmt.Sprintf("%d/%d", g.Done(), len(g.Commands))
+
+	columns := ""
+	switch {
+	case available-durationColumnWidth-exitColumnWidth >= minLabelWidth:
+		columns = padLeft(duration, durationColumnWidth) + padLeft(progress, exitColumnWidth)
+	case available-durationColumnWidth >= minLabelWidth:
+		columns = padLeft(duration, durationColumnWidth)
+	}
 
-	// Truncate long commands
-	maxLen := 80
-	if len(cmdText) > maxLen {
-		cmdText = cmdText[:maxLen-3] + "..."
+	labelWidth := max(available-ansi.StringWidth(columns), 1)
+	label := ansi.Truncate(g.Name, labelWidth, "…")
+	if columns != "" {
+		label += strings.Repeat(" ", labelWidth-ansi.StringWidth(label))
 	}
 
-	line := icon + " " + cmdText
+	return styleLine(left+label+columns, status, isSelected)
+}
 
+// styleLine colors a list row by status and adds the selection marker
+func styleLine(line string, status executor.CommandStatus, isSelected bool) string {
 	// Apply styling based on status
-	switch cmd.Status {
+	switch status {
 	case executor.StatusRunning:
 		line = RunningStyle.Render(line)
-	case executor.StatusCompleted:
+	case executor.StatusCompleted, executor.StatusCached:
 		line = SuccessStyle.Render(line)
 	case executor.StatusFailed:
 		line = ErrorStyle.Render(line)
@@ -91,14 +138,56 @@ func FormatCommandLineWithSpinner(cmd *executor.Command, isSelected bool, spinne
 		line = SkippedStyle.Render(line)
 	case executor.StatusPending:
 		line = PendingStyle.Render(line)
+	case executor.StatusWaiting, executor.StatusPaused:
+		line = PromptStyle.Render(line)
 	}
 
 	// Highlight if selected
 	if isSelected {
-		line = SelectedStyle.Render("> " + line)
-	} else {
-		line = "  " + line
+		return SelectedStyle.Render("> " + line)
+	}
+	return "  " + line
+}
+
+// FormatDuration returns a compact duration for a command, or an empty string
+// if it has not started
+func FormatDuration(cmd *executor.Command) string {
+	if cmd.StartTime.IsZero() {
+		return ""
+	}
+
+	return formatElapsed(cmd.Duration())
+}
+
+// formatElapsed formats a duration compactly for the list columns
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// exitColumn returns the exit code column for finished commands
+func exitColumn(cmd *executor.Command) string {
+	if cmd.Status == executor.StatusCached {
+		return "cached"
+	}
+	if cmd.Status != executor.StatusCompleted && cmd.Status != executor.StatusFailed {
+		return ""
 	}
+	return fmt.Sprintf("exit %d", cmd.ExitCode)
+}
 
-	return line
+// padLeft right-aligns s within a column of the given display width. Text
+// that would fill the column is truncated, keeping a space before it.
+func padLeft(s string, width int) string {
+	w := ansi.StringWidth(s)
+	if w >= width {
+		return " " + ansi.Truncate(s, width-1, "…")
+	}
+	return strings.Repeat(" ", width-w) + s
 }
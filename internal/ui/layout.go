@@ -45,7 +45,7 @@ func (l Layout) Render(left, right string) string {
 		Width(l.LeftWidth()).
 		Height(l.Height).
 		Border(lipgloss.NormalBorder(), false, true, false, false).
-		BorderForeground(lipgloss.Color("240")).
+		BorderForeground(BorderStyle.GetBorderRightForeground()).
 		Render(left)
 
 	rightPanel := lipgloss.NewStyle().
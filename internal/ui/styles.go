@@ -3,54 +3,115 @@ package ui
 import "github.com/charmbracelet/lipgloss"
 
 var (
-	// Color definitions
-	colorGreen  = lipgloss.Color("46")
-	colorRed    = lipgloss.Color("196")
-	colorYellow = lipgloss.Color("226")
-	colorBlue   = lipgloss.Color("39")
-	colorGray   = lipgloss.Color("240")
-	colorPurple = lipgloss.Color("170")
-
 	// SelectedStyle is used for the currently selected command
-	SelectedStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorPurple)
+	SelectedStyle lipgloss.Style
 
 	// ErrorStyle is used for error messages
-	ErrorStyle = lipgloss.NewStyle().
-			Foreground(colorRed).
-			Bold(true)
+	ErrorStyle lipgloss.Style
 
 	// SuccessStyle is used for success messages
-	SuccessStyle = lipgloss.NewStyle().
-			Foreground(colorGreen)
+	SuccessStyle lipgloss.Style
 
 	// RunningStyle is used for currently running commands
-	RunningStyle = lipgloss.NewStyle().
-			Foreground(colorBlue).
-			Bold(true)
+	RunningStyle lipgloss.Style
 
 	// PendingStyle is used for pending commands
-	PendingStyle = lipgloss.NewStyle().
-			Foreground(colorGray)
+	PendingStyle lipgloss.Style
 
 	// SkippedStyle is used for skipped commands
-	SkippedStyle = lipgloss.NewStyle().
-			Foreground(colorGray).
-			Italic(true)
+	SkippedStyle lipgloss.Style
 
 	// BorderStyle is used for panel borders
-	BorderStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(colorGray)
+	BorderStyle lipgloss.Style
 
 	// TitleStyle is used for panel titles
-	TitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorPurple)
+	TitleStyle lipgloss.Style
+
+	// MatchStyle highlights search matches in the output viewer
+	MatchStyle lipgloss.Style
+
+	// CurrentMatchStyle highlights the selected search match
+	CurrentMatchStyle lipgloss.Style
+
+	// StatusBarStyle is used for the output viewer status bar
+	StatusBarStyle lipgloss.Style
 
 	// PromptStyle is used for prompts
-	PromptStyle = lipgloss.NewStyle().
-			Foreground(colorYellow).
-			Bold(true)
+	PromptStyle lipgloss.Style
+
+	// HelpKeyStyle and HelpDescStyle are used for key hints
+	HelpKeyStyle  lipgloss.Style
+	HelpDescStyle lipgloss.Style
 )
+
+func init() {
+	ApplyTheme(themes["dark"])
+}
+
+// ApplyTheme rebuilds the styles from a theme's palette
+func ApplyTheme(t Theme) {
+	SelectedStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Accent)
+
+	ErrorStyle = lipgloss.NewStyle().
+		Foreground(t.Error).
+		Bold(true)
+
+	SuccessStyle = lipgloss.NewStyle().
+		Foreground(t.Success)
+
+	RunningStyle = lipgloss.NewStyle().
+		Foreground(t.Running).
+		Bold(true)
+
+	PendingStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+
+	SkippedStyle = lipgloss.NewStyle().
+		Foreground(t.Muted).
+		Italic(true)
+
+	BorderStyle = lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.Muted)
+
+	TitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Accent)
+
+	MatchStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(t.Warning)
+
+	CurrentMatchStyle = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(t.Accent).
+		Bold(true)
+
+	StatusBarStyle = lipgloss.NewStyle().
+		Foreground(t.Muted).
+		Reverse(true)
+
+	PromptStyle = lipgloss.NewStyle().
+		Foreground(t.Warning).
+		Bold(true)
+
+	HelpKeyStyle = lipgloss.NewStyle().
+		Foreground(t.Accent)
+
+	HelpDescStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+
+	// Without color, distinguish states by text attributes alone
+	if t.Monochrome {
+		SelectedStyle = SelectedStyle.Reverse(true)
+		PendingStyle = PendingStyle.Faint(true)
+		SkippedStyle = SkippedStyle.Faint(true)
+		MatchStyle = lipgloss.NewStyle().Underline(true)
+		CurrentMatchStyle = lipgloss.NewStyle().Reverse(true).Bold(true)
+		StatusBarStyle = lipgloss.NewStyle().Reverse(true)
+		HelpKeyStyle = lipgloss.NewStyle().Bold(true)
+		HelpDescStyle = lipgloss.NewStyle()
+	}
+}
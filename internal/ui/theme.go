@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is a named color palette for the UI
+type Theme struct {
+	Name    string
+	Success lipgloss.TerminalColor
+	Error   lipgloss.TerminalColor
+	Running lipgloss.TerminalColor
+	Muted   lipgloss.TerminalColor // Pending and skipped commands, borders, hints
+	Accent  lipgloss.TerminalColor // Selection and titles
+	Warning lipgloss.TerminalColor // Prompts and search matches
+
+	// Monochrome themes rely on bold, underline and reverse video instead of color
+	Monochrome bool
+}
+
+// themes are the built-in palettes, selected by name
+var themes = map[string]Theme{
+	"dark": {
+		Name:    "dark",
+		Success: lipgloss.Color("46"),
+		Error:   lipgloss.Color("196"),
+		Running: lipgloss.Color("39"),
+		Muted:   lipgloss.Color("240"),
+		Accent:  lipgloss.Color("170"),
+		Warning: lipgloss.Color("226"),
+	},
+	"light": {
+		Name:    "light",
+		Success: lipgloss.Color("28"),
+		Error:   lipgloss.Color("160"),
+		Running: lipgloss.Color("25"),
+		Muted:   lipgloss.Color("244"),
+		Accent:  lipgloss.Color("91"),
+		Warning: lipgloss.Color("130"),
+	},
+	"high-contrast": {
+		Name:    "high-contrast",
+		Success: lipgloss.Color("10"),
+		Error:   lipgloss.Color("9"),
+		Running: lipgloss.Color("14"),
+		Muted:   lipgloss.Color("15"),
+		Accent:  lipgloss.Color("13"),
+		Warning: lipgloss.Color("11"),
+	},
+	"monochrome": {
+		Name:       "monochrome",
+		Success:    lipgloss.NoColor{},
+		Error:      lipgloss.NoColor{},
+		Running:    lipgloss.NoColor{},
+		Muted:      lipgloss.NoColor{},
+		Accent:     lipgloss.NoColor{},
+		Warning:    lipgloss.NoColor{},
+		Monochrome: true,
+	},
+}
+
+// ThemeNames returns the names accepted by LoadTheme, sorted
+func ThemeNames() []string {
+	names := []string{"auto"}
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadTheme returns the named theme. "auto" (or an empty name) picks dark or
+// light from the terminal's background color.
+func LoadTheme(name string) (Theme, error) {
+	if name == "" || name == "auto" {
+		if lipgloss.HasDarkBackground() {
+			return themes["dark"], nil
+		}
+		return themes["light"], nil
+	}
+
+	theme, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (valid: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return theme, nil
+}
+
+// IconSet maps command statuses to the icons shown in the list
+type IconSet map[executor.CommandStatus]string
+
+// iconSets are the built-in icon sets, selected by name
+var iconSets = map[string]IconSet{
+	"emoji": {
+		executor.StatusPending:   "⏳",
+		executor.StatusRunning:   "▶️ ",
+		executor.StatusCompleted: "✔",
+		executor.StatusFailed:    "x",
+		executor.StatusSkipped:   "⊘ ",
+		executor.StatusWaiting:   "⏸ ",
+		executor.StatusPaused:    "💤",
+		executor.StatusCached:    "⚡",
+	},
+	// ASCII icons have a fixed width on every terminal and font
+	"ascii": {
+		executor.StatusPending:   "[ ]",
+		executor.StatusRunning:   "[>]",
+		executor.StatusCompleted: "[+]",
+		executor.StatusFailed:    "[x]",
+		executor.StatusSkipped:   "[-]",
+		executor.StatusWaiting:   "[?]",
+		executor.StatusPaused:    "[=]",
+		executor.StatusCached:    "[*]",
+	},
+}
+
+// foldMarkers are the markers shown before expanded and collapsed group headers
+type foldMarkers struct {
+	expanded  string
+	collapsed string
+}
+
+// foldSets pairs each icon set with matching group fold markers
+var foldSets = map[string]foldMarkers{
+	"emoji": {expanded: "▾ ", collapsed: "▸ "},
+	"ascii": {expanded: "- ", collapsed: "+ "},
+}
+
+// icons and folds are the icon set in use
+var (
+	icons = iconSets["emoji"]
+	folds = foldSets["emoji"]
+)
+
+// IconSetNames returns the names accepted by SetIcons, sorted
+func IconSetNames() []string {
+	names := make([]string, 0, len(iconSets))
+	for name := range iconSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetIcons selects the named icon set
+func SetIcons(name string) error {
+	set, ok := iconSets[name]
+	if !ok {
+		return fmt.Errorf("unknown icon set %q (valid: %s)", name, strings.Join(IconSetNames(), ", "))
+	}
+	icons = set
+	folds = foldSets[name]
+	return nil
+}
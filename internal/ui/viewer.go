@@ -0,0 +1,422 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/alameenkhader/lazycommands/internal/clipboard"
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/keys"
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// match is a single search hit within the output
+type match struct {
+	line  int
+	start int // Byte offset of the match within the line
+	end   int
+}
+
+// Viewer is a scrollable, searchable view of a command's output
+type Viewer struct {
+	viewport viewport.Model
+	input    textinput.Model
+	keys     keys.KeyMap
+	cmd      *executor.Command
+	lines    []string // Snapshot of the command's output
+
+	searching    bool // Search input has focus
+	searchOrigin int  // Scroll offset when the search started
+	query        string
+	matches      []match
+	current      int // Index into matches of the selected match
+	follow       bool
+
+	notice   string // Transient message shown in the status bar
+	noticeID int    // Incremented per notice so stale expiries are ignored
+}
+
+// NoticeExpiredMsg clears a transient status bar notice
+type NoticeExpiredMsg struct {
+	id int
+}
+
+// noticeDuration is how long status bar notices stay visible
+const noticeDuration = 2 * time.Second
+
+// NewViewer creates an output viewer using the given key bindings
+func NewViewer(keyMap keys.KeyMap) Viewer {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Cursor.SetMode(cursor.CursorStatic)
+
+	return Viewer{
+		viewport: viewport.New(0, 0),
+		input:    input,
+		keys:     keyMap,
+	}
+}
+
+// SetCommand switches the viewer to a command's output. Output of a running
+// command is followed by default.
+func (v *Viewer) SetCommand(cmd *executor.Command) {
+	if v.cmd == cmd {
+		v.Refresh()
+		return
+	}
+
+	v.cmd = cmd
+	v.lines = nil
+	v.searching = false
+	v.query = ""
+	v.matches = nil
+	v.current = 0
+	v.follow = cmd.Active()
+	v.input.Blur()
+	v.input.SetValue("")
+	v.viewport.SetYOffset(0)
+	v.Refresh()
+
+	// Finished commands open at the end, where failures usually are
+	if !v.follow {
+		v.viewport.GotoBottom()
+	}
+}
+
+// Command returns the command being viewed
+func (v Viewer) Command() *executor.Command {
+	return v.cmd
+}
+
+// SetSize sets the viewer dimensions, including the status bar
+func (v *Viewer) SetSize(width, height int) {
+	if height < 2 {
+		height = 2
+	}
+	v.viewport.Width = width
+	v.viewport.Height = height - 1
+	v.input.Width = width - 2
+
+	// Re-clamp the scroll position to the new height
+	v.viewport.SetYOffset(v.viewport.YOffset)
+	if v.follow {
+		v.viewport.GotoBottom()
+	}
+}
+
+// Searching reports whether the search input has focus
+func (v Viewer) Searching() bool {
+	return v.searching
+}
+
+// Following reports whether the viewer is tailing the output
+func (v Viewer) Following() bool {
+	return v.follow
+}
+
+// Refresh re-reads the command's output, keeping the view at the bottom
+// when following
+func (v *Viewer) Refresh() {
+	if v.cmd == nil {
+		return
+	}
+
+	v.lines = append(v.lines[:0], v.cmd.Output...)
+	if partial := v.cmd.PartialLine(); partial != "" {
+		// Show a prompt before its line is finished
+		v.lines = append(v.lines, partial)
+	}
+	v.findMatches()
+	v.render()
+
+	// Stop following once the command has finished
+	if v.follow {
+		v.viewport.GotoBottom()
+		if !v.cmd.Active() {
+			v.follow = false
+		}
+	}
+}
+
+// Update handles key presses for scrolling and search
+func (v Viewer) Update(msg tea.KeyMsg) (Viewer, tea.Cmd) {
+	if v.searching {
+		return v.updateSearch(msg)
+	}
+
+	switch {
+	case key.Matches(msg, v.keys.Up):
+		v.follow = false
+		v.viewport.ScrollUp(1)
+	case key.Matches(msg, v.keys.Down):
+		v.viewport.ScrollDown(1)
+	case key.Matches(msg, v.keys.PageUp):
+		v.follow = false
+		v.viewport.PageUp()
+	case key.Matches(msg, v.keys.PageDown):
+		v.viewport.PageDown()
+	case key.Matches(msg, v.keys.Top):
+		v.follow = false
+		v.viewport.GotoTop()
+	case key.Matches(msg, v.keys.Bottom):
+		// Jumping to the end of a running command resumes following it
+		v.follow = v.cmd != nil && v.cmd.Active()
+		v.viewport.GotoBottom()
+	case key.Matches(msg, v.keys.Follow):
+		v.follow = !v.follow && v.cmd != nil && v.cmd.Active()
+		if v.follow {
+			v.viewport.GotoBottom()
+		}
+	case key.Matches(msg, v.keys.Search):
+		v.searching = true
+		v.searchOrigin = v.viewport.YOffset
+		v.input.SetValue("")
+		return v, v.input.Focus()
+	case key.Matches(msg, v.keys.NextMatch):
+		v.jump(1)
+	case key.Matches(msg, v.keys.PrevMatch):
+		v.jump(-1)
+	case key.Matches(msg, v.keys.CopyCommand):
+		if v.cmd != nil {
+			// Secrets stay masked, as the clipboard may be shared or synced
+			return v, v.copy(v.cmd.Secrets.Mask(v.cmd.Raw), "command")
+		}
+	case key.Matches(msg, v.keys.CopyOutput):
+		return v, v.copy(strings.Join(v.lines, "\n"), fmt.Sprintf("output (%d lines)", len(v.lines)))
+	case key.Matches(msg, v.keys.CopyMatch):
+		if len(v.matches) == 0 {
+			return v, v.showNotice("No search match to copy")
+		}
+		return v, v.copy(v.lines[v.matches[v.current].line], "matching line")
+	}
+
+	return v, nil
+}
+
+// Scroll moves the view by delta lines, as the mouse wheel does. Scrolling up
+// stops following the output.
+func (v *Viewer) Scroll(delta int) {
+	if delta < 0 {
+		v.follow = false
+		v.viewport.ScrollUp(-delta)
+		return
+	}
+	v.viewport.ScrollDown(delta)
+}
+
+// ExpireNotice clears the status bar notice if it is the one the message refers to
+func (v *Viewer) ExpireNotice(msg NoticeExpiredMsg) {
+	if msg.id == v.noticeID {
+		v.notice = ""
+	}
+}
+
+// copy places text on the clipboard and reports the result in the status bar
+func (v *Viewer) copy(text, what string) tea.Cmd {
+	if err := clipboard.Copy(text); err != nil {
+		return v.showNotice(fmt.Sprintf("Copy failed: %v", err))
+	}
+	return v.showNotice(fmt.Sprintf("Copied %s to clipboard", what))
+}
+
+// showNotice displays a transient message in the status bar
+func (v *Viewer) showNotice(text string) tea.Cmd {
+	v.noticeID++
+	id := v.noticeID
+	v.notice = text
+	return tea.Tick(noticeDuration, func(time.Time) tea.Msg {
+		return NoticeExpiredMsg{id: id}
+	})
+}
+
+// updateSearch handles key presses while the search input has focus
+func (v Viewer) updateSearch(msg tea.KeyMsg) (Viewer, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		// Keep the query and return to scrolling
+		v.searching = false
+		v.input.Blur()
+		return v, nil
+	case tea.KeyEsc:
+		// Abandon the search and return to where it started
+		v.searching = false
+		v.input.Blur()
+		v.query = ""
+		v.findMatches()
+		v.render()
+		v.viewport.SetYOffset(v.searchOrigin)
+		return v, nil
+	}
+
+	var cmd tea.Cmd
+	v.input, cmd = v.input.Update(msg)
+
+	// Incremental search: jump to the first match below the starting point
+	if v.input.Value() != v.query {
+		v.query = v.input.Value()
+		v.findMatches()
+		v.current = 0
+		for i, m := range v.matches {
+			if m.line >= v.searchOrigin {
+				v.current = i
+				break
+			}
+		}
+		v.render()
+		if len(v.matches) > 0 {
+			v.follow = false
+			v.reveal(v.matches[v.current].line)
+		} else {
+			v.viewport.SetYOffset(v.searchOrigin)
+		}
+	}
+
+	return v, cmd
+}
+
+// jump moves the selected match forwards or backwards, wrapping around
+func (v *Viewer) jump(delta int) {
+	if len(v.matches) == 0 {
+		return
+	}
+	v.follow = false
+	v.current = (v.current + delta + len(v.matches)) % len(v.matches)
+	v.render()
+	v.reveal(v.matches[v.current].line)
+}
+
+// reveal scrolls so that the given line is visible, centring it if needed
+func (v *Viewer) reveal(line int) {
+	top := v.viewport.YOffset
+	if line < top || line >= top+v.viewport.Height {
+		v.viewport.SetYOffset(line - v.viewport.Height/2)
+	}
+}
+
+// findMatches locates all occurrences of the query. The search is case
+// insensitive unless the query contains an upper-case letter.
+func (v *Viewer) findMatches() {
+	v.matches = v.matches[:0]
+	if v.query == "" {
+		return
+	}
+
+	query := v.query
+	fold := !hasUpper(query)
+	if fold {
+		query = strings.ToLower(query)
+	}
+
+	for i, line := range v.lines {
+		haystack := line
+		if fold {
+			haystack = strings.ToLower(line)
+			// Lower-casing changed byte offsets; fall back to an exact search
+			if len(haystack) != len(line) {
+				haystack = line
+			}
+		}
+
+		offset := 0
+		for {
+			idx := strings.Index(haystack[offset:], query)
+			if idx < 0 {
+				break
+			}
+			start := offset + idx
+			v.matches = append(v.matches, match{line: i, start: start, end: start + len(query)})
+			offset = start + len(query)
+		}
+	}
+
+	if v.current >= len(v.matches) {
+		v.current = 0
+	}
+}
+
+// render rebuilds the viewport content with search matches highlighted
+func (v *Viewer) render() {
+	var b strings.Builder
+	next := 0
+
+	for i, line := range v.lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		pos := 0
+		for next < len(v.matches) && v.matches[next].line == i {
+			m := v.matches[next]
+			style := MatchStyle
+			if next == v.current {
+				style = CurrentMatchStyle
+			}
+			b.WriteString(line[pos:m.start])
+			b.WriteString(style.Render(line[m.start:m.end]))
+			pos = m.end
+			next++
+		}
+		b.WriteString(line[pos:])
+	}
+
+	v.viewport.SetContent(b.String())
+}
+
+// View renders the output followed by the status bar or search input
+func (v Viewer) View() string {
+	return v.viewport.View() + "\n" + v.statusBar()
+}
+
+// statusBar describes the scroll position, search results and follow state
+func (v Viewer) statusBar() string {
+	if v.searching {
+		return v.input.View()
+	}
+
+	total := len(v.lines)
+	first := 0
+	last := 0
+	if total > 0 {
+		first = v.viewport.YOffset + 1
+		last = v.viewport.YOffset + v.viewport.VisibleLineCount()
+	}
+
+	parts := []string{fmt.Sprintf("lines %d-%d/%d", first, last, total)}
+	if v.notice != "" {
+		parts = append([]string{v.notice}, parts...)
+	}
+	if v.query != "" {
+		if len(v.matches) == 0 {
+			parts = append(parts, fmt.Sprintf("/%s: no matches", v.query))
+		} else {
+			parts = append(parts, fmt.Sprintf("/%s: %d/%d", v.query, v.current+1, len(v.matches)))
+		}
+	}
+	if v.follow {
+		parts = append(parts, "FOLLOW")
+	}
+
+	bar := " " + strings.Join(parts, " • ") + " "
+	if w := v.viewport.Width; w > 0 {
+		bar = ansi.Truncate(bar, w, "…")
+		bar += strings.Repeat(" ", w-ansi.StringWidth(bar))
+	}
+	return StatusBarStyle.Render(bar)
+}
+
+// hasUpper reports whether s contains an upper-case letter
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
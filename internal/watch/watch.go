@@ -0,0 +1,104 @@
+// Package watch detects changes to files matching glob patterns by polling
+// the file system, so it works the same on every platform
+package watch
+
+import (
+	"io/fs"
+	"sort"
+	"time"
+
+	"github.com/alameenkhader/lazycommands/internal/glob"
+)
+
+const (
+	// pollInterval is how often the watched files are checked
+	pollInterval = 500 * time.Millisecond
+
+	// debounce is how long files must stay unchanged before a change is
+	// reported, so saving several files triggers one run
+	debounce = 300 * time.Millisecond
+)
+
+// Watcher reports changes to the files matching its patterns
+type Watcher struct {
+	root     string
+	globs    []string
+	patterns []*glob.Pattern
+	files    map[string]fileState
+}
+
+// fileState is what a file looked like at the last scan
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// New creates a watcher for the files under root matching any of the globs.
+// Globs use forward slashes and are relative to root; `*` and `?` match
+// within a path segment and `**` matches any number of directories.
+func New(root string, globs []string) (*Watcher, error) {
+	patterns, err := glob.CompileAll(globs)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{root: root, globs: globs, patterns: patterns}
+	w.files = w.scan()
+	return w, nil
+}
+
+// Globs returns the patterns being watched
+func (w *Watcher) Globs() []string {
+	return w.globs
+}
+
+// Wait blocks until matching files change and then stay unchanged for the
+// debounce period. It returns the first file that changed, relative to root.
+func (w *Watcher) Wait() string {
+	trigger := ""
+	var last time.Time
+	for {
+		time.Sleep(pollInterval)
+
+		files := w.scan()
+		changed := diff(w.files, files)
+		w.files = files
+
+		switch {
+		case len(changed) > 0:
+			if trigger == "" {
+				trigger = changed[0]
+			}
+			last = time.Now()
+		case trigger != "" && time.Since(last) >= debounce:
+			return trigger
+		}
+	}
+}
+
+// scan records the state of every matching file
+func (w *Watcher) scan() map[string]fileState {
+	files := make(map[string]fileState)
+	glob.Walk(w.root, w.patterns, func(rel string, d fs.DirEntry) {
+		if info, err := d.Info(); err == nil {
+			files[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	})
+	return files
+}
+
+// diff returns the files added, changed or removed between two scans, sorted
+func diff(before, after map[string]fileState) []string {
+	var changed []string
+	for p, state := range after {
+		if old, ok := before[p]; !ok || old != state {
+			changed = append(changed, p)
+		}
+	}
+	for p := range before {
+		if _, ok := after[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
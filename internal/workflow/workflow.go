@@ -0,0 +1,266 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/expr"
+	"github.com/alameenkhader/lazycommands/internal/glob"
+	"github.com/alameenkhader/lazycommands/internal/hooks"
+)
+
+// Workflow is a sequence of steps loaded from a workflow file
+type Workflow struct {
+	Steps []Step `json:"steps"`
+
+	// OnSuccess and OnFailure run once the whole run has finished
+	OnSuccess []hooks.Hook `json:"on_success,omitempty"`
+	OnFailure []hooks.Hook `json:"on_failure,omitempty"`
+}
+
+// Step is a single command in a workflow
+type Step struct {
+	ID   string `json:"id,omitempty"`   // Identifier conditions use to refer to the step
+	Name string `json:"name,omitempty"` // Display name shown in the list, summary and logs
+	Run  string `json:"run,omitempty"`  // Shell command to execute
+
+	// Group collects consecutive steps under a collapsible header
+	Group string `json:"group,omitempty"`
+
+	// Pause makes the step an approval gate: the run stops and asks this
+	// question, continuing only if it is approved
+	Pause string `json:"pause,omitempty"`
+
+	// Inputs are globs of the files the step's result depends on. A step with
+	// inputs is skipped when a cached successful result matches them.
+	Inputs []string `json:"inputs,omitempty"`
+
+	// Outputs are globs of the files the step produces, saved with its cached
+	// result and restored when the step is skipped
+	Outputs []string `json:"outputs,omitempty"`
+
+	// If is a condition evaluated just before the step would run, such as
+	// `steps.test.status == "failed"`. The step is skipped when it does not hold.
+	If string `json:"if,omitempty"`
+
+	// Always makes the step a cleanup step that runs even when an earlier step
+	// failed or the run was stopped
+	Always bool `json:"always,omitempty"`
+
+	// OnSuccess and OnFailure run when the step finishes
+	OnSuccess []hooks.Hook `json:"on_success,omitempty"`
+	OnFailure []hooks.Hook `json:"on_failure,omitempty"`
+
+	// Secret marks the step's output as secret: it is shown as *** and masked
+	// wherever it appears later
+	Secret bool `json:"secret,omitempty"`
+
+	// Input keeps the step's stdin open while it runs, so its prompts can be
+	// answered by attaching to it. Other steps read end-of-file.
+	Input bool `json:"input,omitempty"`
+}
+
+// stepIDPattern is the form of a step id, usable in `steps.<id>` references
+var stepIDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Load reads and validates a workflow file
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+
+	wf, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return wf, nil
+}
+
+// Parse decodes and validates workflow JSON
+func Parse(data []byte) (*Workflow, error) {
+	var wf Workflow
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&wf); err != nil {
+		return nil, describeJSONError(data, err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the workflow object")
+	}
+
+	if err := wf.validate(); err != nil {
+		return nil, err
+	}
+
+	return &wf, nil
+}
+
+// validate checks that every step can be executed
+func (w *Workflow) validate() error {
+	if len(w.Steps) == 0 {
+		return errors.New("workflow has no steps")
+	}
+	if err := validateHooks("", w.OnSuccess, w.OnFailure); err != nil {
+		return err
+	}
+
+	// Groups must be contiguous so each has a single header in the list
+	seen := make(map[string]bool)
+	previous := ""
+	ids := make(map[string]bool)
+	for i, step := range w.Steps {
+		run, pause := strings.TrimSpace(step.Run), strings.TrimSpace(step.Pause)
+		switch {
+		case run != "" && pause != "":
+			return fmt.Errorf("steps[%d]: a step has either \"run\" or \"pause\", not both", i)
+		case run == "" && pause == "":
+			return fmt.Errorf("steps[%d]: missing \"run\"", i)
+		}
+
+		if pause != "" && step.Always {
+			return fmt.Errorf("steps[%d]: approval gates cannot be \"always\"", i)
+		}
+		if pause != "" && step.Input {
+			return fmt.Errorf("steps[%d]: approval gates cannot take \"input\"", i)
+		}
+		if pause != "" && step.Secret {
+			return fmt.Errorf("steps[%d]: approval gates cannot be \"secret\"", i)
+		}
+		if step.Secret && len(step.Inputs) > 0 {
+			// The cache would keep only the masked output
+			return fmt.Errorf("steps[%d]: secret steps cannot be cached with \"inputs\"", i)
+		}
+		if pause != "" && (len(step.OnSuccess) > 0 || len(step.OnFailure) > 0) {
+			return fmt.Errorf("steps[%d]: approval gates cannot have hooks", i)
+		}
+		if err := validateHooks(fmt.Sprintf("steps[%d].", i), step.OnSuccess, step.OnFailure); err != nil {
+			return err
+		}
+		if pause != "" && (len(step.Inputs) > 0 || len(step.Outputs) > 0) {
+			return fmt.Errorf("steps[%d]: approval gates cannot have \"inputs\" or \"outputs\"", i)
+		}
+		if len(step.Outputs) > 0 && len(step.Inputs) == 0 {
+			return fmt.Errorf("steps[%d]: \"outputs\" are only cached for steps with \"inputs\"", i)
+		}
+		if _, err := glob.CompileAll(step.Inputs); err != nil {
+			return fmt.Errorf("steps[%d].inputs: %w", i, err)
+		}
+		if _, err := glob.CompileAll(step.Outputs); err != nil {
+			return fmt.Errorf("steps[%d].outputs: %w", i, err)
+		}
+
+		if cond := strings.TrimSpace(step.If); cond != "" {
+			e, err := expr.Parse(cond)
+			if err != nil {
+				return fmt.Errorf("steps[%d].if: %w", i, err)
+			}
+			// Conditions can only look at steps that have already had their turn
+			for _, ref := range e.Steps() {
+				if !ids[ref] {
+					return fmt.Errorf("steps[%d].if: no earlier step has id %q", i, ref)
+				}
+			}
+		}
+
+		if step.ID != "" {
+			if !stepIDPattern.MatchString(step.ID) {
+				return fmt.Errorf("steps[%d].id: %q must start with a letter or _ and contain only letters, digits, _ and -", i, step.ID)
+			}
+			if ids[step.ID] {
+				return fmt.Errorf("steps[%d].id: %q is used by an earlier step", i, step.ID)
+			}
+			ids[step.ID] = true
+		}
+
+		group := strings.TrimSpace(step.Group)
+		if group != "" && group != previous && seen[group] {
+			return fmt.Errorf("steps[%d]: group %q must be contiguous", i, group)
+		}
+		seen[group] = true
+		previous = group
+	}
+
+	return nil
+}
+
+// Commands builds the executable command list for the workflow
+func (w *Workflow) Commands() []*executor.Command {
+	commands := make([]*executor.Command, 0, len(w.Steps))
+	for i, step := range w.Steps {
+		cmd := executor.NewCommand(i, strings.TrimSpace(step.Run))
+		cmd.StepID = step.ID
+		cmd.Name = strings.TrimSpace(step.Name)
+		cmd.If = strings.TrimSpace(step.If)
+		cmd.Always = step.Always
+		cmd.Hooks = hooks.Set{OnSuccess: step.OnSuccess, OnFailure: step.OnFailure}
+		cmd.Secret = step.Secret
+		cmd.AcceptInput = step.Input
+		cmd.Group = strings.TrimSpace(step.Group)
+		cmd.Pause = strings.TrimSpace(step.Pause)
+		cmd.Inputs = step.Inputs
+		cmd.Outputs = step.Outputs
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
+// Hooks returns the hooks that run once the whole run has finished
+func (w *Workflow) Hooks() hooks.Set {
+	return hooks.Set{OnSuccess: w.OnSuccess, OnFailure: w.OnFailure}
+}
+
+// validateHooks checks the on_success and on_failure hooks of the workflow or
+// of a step, whose path prefixes the errors
+func validateHooks(prefix string, onSuccess, onFailure []hooks.Hook) error {
+	for i, h := range onSuccess {
+		if err := h.Validate(); err != nil {
+			return fmt.Errorf("%son_success[%d]: %w", prefix, i, err)
+		}
+	}
+	for i, h := range onFailure {
+		if err := h.Validate(); err != nil {
+			return fmt.Errorf("%son_failure[%d]: %w", prefix, i, err)
+		}
+	}
+	return nil
+}
+
+// describeJSONError adds line and column information to JSON decoding errors
+func describeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := position(data, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, col, syntaxErr)
+	case errors.As(err, &typeErr):
+		line, col := position(data, typeErr.Offset)
+		return fmt.Errorf("line %d, column %d: %q must be a %s", line, col, typeErr.Field, typeErr.Type)
+	case errors.Is(err, io.EOF):
+		return errors.New("workflow file is empty")
+	default:
+		return err
+	}
+}
+
+// position converts a byte offset into a 1-based line and column
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
@@ -1,37 +1,129 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/alameenkhader/lazycommands/internal/app"
+	"github.com/alameenkhader/lazycommands/internal/cache"
+	"github.com/alameenkhader/lazycommands/internal/config"
 	"github.com/alameenkhader/lazycommands/internal/executor"
+	"github.com/alameenkhader/lazycommands/internal/hooks"
+	"github.com/alameenkhader/lazycommands/internal/log"
+	"github.com/alameenkhader/lazycommands/internal/parser"
+	"github.com/alameenkhader/lazycommands/internal/record"
+	"github.com/alameenkhader/lazycommands/internal/secrets"
+	"github.com/alameenkhader/lazycommands/internal/ui"
 	"github.com/alameenkhader/lazycommands/internal/version"
+	"github.com/alameenkhader/lazycommands/internal/watch"
+	"github.com/alameenkhader/lazycommands/internal/workflow"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
 )
 
+// options holds the parsed command-line flags
+type options struct {
+	showVersion  bool
+	workflowFile string
+	configPath   string
+	shell        string
+	logFormat    string
+	theme        string
+	icons        string
+	fullscreen   bool
+	confirm      bool
+	approve      bool
+	input        bool
+	headless     bool
+	watch        stringList
+	noCache      bool
+	cacheDir     string
+	onSuccess    stringList
+	onFailure    stringList
+	notify       stringList
+	secretEnv    stringList
+	secretRegex  stringList
+	logDir       string
+	noLog        bool
+	record       string
+	recordSteps  bool
+}
+
+// stringList collects the values of a repeatable flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 func main() {
+	opts, args, err := parseFlags(os.Args[1:])
+	if err != nil {
+		os.Exit(2)
+	}
+
 	// Handle version flag
-	if len(os.Args) == 2 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
+	if opts.showVersion {
 		printVersion()
 		os.Exit(0)
 	}
 
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Print the effective configuration
+	if len(args) == 1 && args[0] == "config" {
+		printConfig(opts, cfg)
+		os.Exit(0)
+	}
+
+	// Show the steps of an earlier run from its log
+	if len(args) >= 1 && args[0] == "view" {
+		os.Exit(viewLog(args[1:], cfg))
+	}
+
 	var commands []*executor.Command
+	var runHooks hooks.Set
 
 	// Check if stdin has data (piped input)
 	stat, _ := os.Stdin.Stat()
 	hasStdin := (stat.Mode() & os.ModeCharDevice) == 0
 
-	if hasStdin {
+	if opts.workflowFile != "" {
+		// Load steps from a workflow file
+		wf, err := workflow.Load(opts.workflowFile)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		commands = wf.Commands()
+		runHooks = wf.Hooks()
+	} else if hasStdin {
 		// Read commands from stdin (one per line)
-		commands = readCommandsFromStdin()
-	} else if len(os.Args) >= 2 {
+		var err error
+		commands, err = readCommandsFromStdin()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if len(args) >= 1 {
 		// Parse commands from arguments
-		commands = make([]*executor.Command, 0, len(os.Args)-1)
-		for i, arg := range os.Args[1:] {
+		commands = make([]*executor.Command, 0, len(args))
+		for i, arg := range args {
 			commands = append(commands, executor.NewCommand(i, arg))
 		}
 	} else {
@@ -45,11 +137,122 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Headless runs the steps in order and prints their output instead
+	headless := opts.headless
+	if headless && opts.confirm {
+		fmt.Println("Error: --confirm needs a terminal")
+		os.Exit(1)
+	}
+	if headless && opts.input {
+		fmt.Println("Error: --input needs a terminal")
+		os.Exit(1)
+	}
+	if opts.input {
+		for _, cmd := range commands {
+			cmd.AcceptInput = true
+		}
+	}
+	if headless && len(opts.watch) > 0 {
+		fmt.Println("Error: --watch needs a terminal")
+		os.Exit(1)
+	}
+
+	var watcher *watch.Watcher
+	if len(opts.watch) > 0 {
+		cwd, err := os.Getwd()
+		if err == nil {
+			watcher, err = watch.New(cwd, opts.watch)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	flagHooks, err := hooksFromFlags(opts)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	runHooks = runHooks.Merge(flagHooks)
+
+	masker, err := secretsFromFlags(opts, cfg)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var stepCache *cache.Cache
+	if !opts.noCache {
+		dir := opts.cacheDir
+		if dir == "" {
+			// Without a cache directory, steps simply always run
+			dir, _ = cache.DefaultDir()
+		}
+		if dir != "" {
+			stepCache = cache.New(dir)
+		}
+	}
+
+	recorder, err := newRecorder(opts)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	applyAppearance(cfg)
+
+	logSettings := log.Settings{
+		Format:     cfg.LogFormat,
+		Disabled:   opts.noLog,
+		Dir:        cfg.LogDir,
+		PerProject: cfg.LogPerProject,
+		Retention:  cfg.LogRetention,
+		Compress:   cfg.LogCompress,
+	}
+
 	// Create the Bubble Tea model
-	model := app.NewModel(commands)
+	model := app.NewModel(commands, app.Options{
+		KeyMap:     cfg.KeyMap(),
+		Shell:      cfg.Shell,
+		Log:        logSettings,
+		Fullscreen: opts.fullscreen,
+		Confirm:    opts.confirm,
+		Approve:    opts.approve,
+		Watcher:    watcher,
+		Cache:      stepCache,
+		Hooks:      runHooks,
+		Secrets:    masker,
+		Recorder:   recorder,
+	})
+
+	if headless {
+		// Commands run in their own process groups, so ctrl+c only reaches
+		// lazycommands, which stops the run and still runs the cleanup steps
+		interrupt := make(chan os.Signal, 2)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		m := app.RunHeadless(model, os.Stdout, interrupt)
+		m.CloseLogger()
+		printSummary(m)
+		printRecording(recorder)
+		os.Exit(m.ExitCode())
+	}
 
-	// Create the program (no alt screen - keep output in terminal)
-	p := tea.NewProgram(model)
+	// Create the program. Inline by default to keep output in the terminal;
+	// fullscreen uses the alternate screen and prints a summary on exit.
+	programOpts := []tea.ProgramOption{}
+	if opts.fullscreen {
+		// Mouse positions only map to rows when the view owns the whole screen
+		programOpts = append(programOpts, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	}
+	if hasStdin {
+		// Piped commands used up stdin, so keys (including input sent to an
+		// attached command) are read from the terminal itself
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			programOpts = append(programOpts, tea.WithInput(tty))
+		}
+	}
+	p := tea.NewProgram(model, programOpts...)
 
 	// Run the program
 	finalModel, err := p.Run()
@@ -64,23 +267,266 @@ func main() {
 		m.CloseLogger()
 
 		fmt.Println() // Add spacing after UI
+		if m.Reviewing() {
+			recorder.Close()
+			fmt.Println("Cancelled during review; nothing was run")
+			os.Exit(1)
+		}
+		if opts.fullscreen {
+			// The alternate screen is gone; leave a record in the scrollback
+			printSteps(m)
+		}
 		printSummary(m)
+		printRecording(recorder)
 		os.Exit(m.ExitCode())
 	}
 
 	os.Exit(0)
 }
 
-// printSummary prints a final summary of what happened
+// parseFlags parses command-line flags and returns the remaining arguments
+func parseFlags(args []string) (options, []string, error) {
+	var opts options
+
+	fs := flag.NewFlagSet("lazycommands", flag.ContinueOnError)
+	fs.Usage = printUsage
+	fs.BoolVar(&opts.showVersion, "version", false, "print version and exit")
+	fs.BoolVar(&opts.showVersion, "v", false, "print version and exit")
+	fs.StringVar(&opts.workflowFile, "file", "", "run the steps in a workflow file")
+	fs.StringVar(&opts.workflowFile, "f", "", "run the steps in a workflow file")
+	fs.StringVar(&opts.configPath, "config", "", "config file to use instead of the default")
+	fs.StringVar(&opts.shell, "shell", "", "shell used to run commands")
+	fs.StringVar(&opts.logFormat, "log-format", "", "debug log format (text or json)")
+	fs.StringVar(&opts.logDir, "log-dir", "", "directory for debug logs")
+	fs.BoolVar(&opts.noLog, "no-log", false, "write no debug log")
+	fs.StringVar(&opts.theme, "theme", "", "color theme")
+	fs.StringVar(&opts.icons, "icons", "", "status icon set (emoji or ascii)")
+	fs.BoolVar(&opts.fullscreen, "fullscreen", false, "use the full terminal (alternate screen)")
+	fs.BoolVar(&opts.confirm, "confirm", false, "review the steps before running them")
+	fs.BoolVar(&opts.approve, "approve", false, "approve every approval gate without asking")
+	fs.BoolVar(&opts.input, "input", false, "keep every step's input open to answer prompts by attaching")
+	fs.BoolVar(&opts.headless, "headless", false, "run without the terminal UI")
+	fs.Var(&opts.watch, "watch", "rerun the commands when files matching the glob change")
+	fs.BoolVar(&opts.noCache, "no-cache", false, "run every step, ignoring cached results")
+	fs.StringVar(&opts.cacheDir, "cache-dir", "", "directory for cached step results")
+	fs.Var(&opts.onSuccess, "on-success", "command to run when the run succeeds")
+	fs.Var(&opts.onFailure, "on-failure", "command to run when the run fails")
+	fs.Var(&opts.notify, "notify", "notify when the run finishes: desktop, bell or a webhook URL")
+	fs.Var(&opts.secretEnv, "secret-env", "mask the value of this environment variable")
+	fs.Var(&opts.secretRegex, "secret-pattern", "mask text matching this regular expression")
+	fs.StringVar(&opts.record, "record", "", "write an asciinema recording of the output to this file")
+	fs.BoolVar(&opts.recordSteps, "record-steps", false, "write one recording per step")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, nil, err
+	}
+
+	return opts, fs.Args(), nil
+}
+
+// hooksFromFlags builds the run's hooks from --on-success, --on-failure and
+// --notify. Notifiers fire whether the run succeeds or fails.
+func hooksFromFlags(opts options) (hooks.Set, error) {
+	var set hooks.Set
+	for _, run := range opts.onSuccess {
+		set.OnSuccess = append(set.OnSuccess, hooks.Hook{Run: run})
+	}
+	for _, run := range opts.onFailure {
+		set.OnFailure = append(set.OnFailure, hooks.Hook{Run: run})
+	}
+	for _, value := range opts.notify {
+		h, err := hooks.ParseNotifier(value)
+		if err != nil {
+			return set, fmt.Errorf("--notify: %w", err)
+		}
+		set.OnSuccess = append(set.OnSuccess, h)
+		set.OnFailure = append(set.OnFailure, h)
+	}
+	return set, nil
+}
+
+// secretsFromFlags registers the values of the --secret-env variables and the
+// secret patterns from the config file and --secret-pattern. Variables that
+// are not set have nothing to mask.
+func secretsFromFlags(opts options, cfg config.Config) (*secrets.Masker, error) {
+	masker := secrets.New()
+	for _, name := range opts.secretEnv {
+		masker.AddValue(os.Getenv(name))
+	}
+	for _, pattern := range cfg.SecretPatterns {
+		if err := masker.AddPattern(pattern); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range opts.secretRegex {
+		if err := masker.AddPattern(pattern); err != nil {
+			return nil, fmt.Errorf("--secret-pattern: %w", err)
+		}
+	}
+	return masker, nil
+}
+
+// newRecorder creates the recorder asked for with --record, sized to the
+// terminal, or returns nil
+func newRecorder(opts options) (*record.Recorder, error) {
+	if opts.record == "" {
+		if opts.recordSteps {
+			return nil, fmt.Errorf("--record-steps needs --record")
+		}
+		return nil, nil
+	}
+
+	width, height := 80, 24
+	if w, h, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 && h > 0 {
+		width, height = w, h
+	}
+	return record.New(opts.record, opts.recordSteps, width, height)
+}
+
+// printRecording closes the recording and says where it was written
+func printRecording(recorder *record.Recorder) {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Close(); err != nil {
+		fmt.Printf("⚠️  Recording incomplete: %v\n", err)
+	}
+
+	files := recorder.Files()
+	switch len(files) {
+	case 0:
+	case 1:
+		fmt.Printf("🎬 Recording saved to: %s\n", files[0])
+	default:
+		fmt.Printf("🎬 %d recordings saved: %s … %s\n", len(files), files[0], files[len(files)-1])
+	}
+}
+
+// loadConfig loads the config file and applies command-line overrides
+func loadConfig(opts options) (config.Config, error) {
+	path := opts.configPath
+	if path == "" {
+		path = config.DefaultPath()
+	} else if _, err := os.Stat(path); err != nil {
+		// An explicitly requested config must exist
+		return config.Config{}, fmt.Errorf("config file: %w", err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	if opts.shell != "" {
+		cfg.Shell = opts.shell
+	}
+	if opts.logDir != "" {
+		cfg.LogDir = opts.logDir
+	}
+	if opts.logFormat != "" {
+		format, err := log.ParseFormat(opts.logFormat)
+		if err != nil {
+			return cfg, fmt.Errorf("--log-format: %w", err)
+		}
+		cfg.LogFormat = format
+	}
+	if opts.theme != "" {
+		if err := config.ValidateTheme(opts.theme); err != nil {
+			return cfg, fmt.Errorf("--theme: %w", err)
+		}
+		cfg.Theme = opts.theme
+	}
+	if opts.icons != "" {
+		if err := config.ValidateIcons(opts.icons); err != nil {
+			return cfg, fmt.Errorf("--icons: %w", err)
+		}
+		cfg.Icons = opts.icons
+	}
+
+	return cfg, nil
+}
+
+// applyAppearance selects the theme and icon set. NO_COLOR forces the
+// monochrome theme so highlights stay visible without color.
+func applyAppearance(cfg config.Config) {
+	name := cfg.Theme
+	if os.Getenv("NO_COLOR") != "" {
+		name = "monochrome"
+
+		// NO_COLOR also disables bold and reverse video in lipgloss; keep those
+		// attributes when the terminal supports them, as every color is unset
+		if termenv.NewOutput(os.Stdout).ColorProfile() != termenv.Ascii {
+			lipgloss.SetColorProfile(termenv.ANSI)
+		}
+	}
+
+	// Names were validated when the config was loaded
+	if theme, err := ui.LoadTheme(name); err == nil {
+		ui.ApplyTheme(theme)
+	}
+	ui.SetIcons(cfg.Icons)
+}
+
+// printConfig prints the config file location and the effective settings
+func printConfig(opts options, cfg config.Config) {
+	path := opts.configPath
+	if path == "" {
+		path = config.DefaultPath()
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		fmt.Printf("# Config file: %s (not found, using defaults)\n", path)
+	} else {
+		fmt.Printf("# Config file: %s\n", path)
+	}
+
+	if cfg.Shell == "" {
+		cfg.Shell = executor.DefaultShell()
+	}
+	fmt.Println(cfg)
+}
+
+// printSteps prints one line per command with its final status
+func printSteps(m app.Model) {
+	groups := executor.Groups(m.Commands())
+	for i, cmd := range m.Commands() {
+		if cmd.Group == "" {
+			fmt.Println(ui.FormatCommandLine(cmd, false, m.Width()))
+			continue
+		}
+
+		// Grouped steps are listed under their group's header
+		for _, g := range groups {
+			if g.Start == i {
+				fmt.Println(ui.FormatGroupLine(g, false, false, "", m.Width()))
+			}
+		}
+		fmt.Println("  " + ui.FormatCommandLine(cmd, false, m.Width()-2))
+	}
+	fmt.Println()
+}
+
+// printSummary prints a final summary of what happened. Cleanup steps are
+// reported on their own, so a failed cleanup is not mistaken for the failure
+// that stopped the run.
 func printSummary(m app.Model) {
 	completed := 0
+	cached := 0
 	failed := 0
 	skipped := 0
 
+	var cleanup []*executor.Command
 	for _, cmd := range m.Commands() {
+		if cmd.Always {
+			cleanup = append(cleanup, cmd)
+			continue
+		}
 		switch cmd.Status {
 		case executor.StatusCompleted:
 			completed++
+		case executor.StatusCached:
+			completed++
+			cached++
 		case executor.StatusFailed:
 			failed++
 		case executor.StatusSkipped:
@@ -88,36 +534,139 @@ func printSummary(m app.Model) {
 		}
 	}
 
-	total := len(m.Commands())
+	total := len(m.Commands()) - len(cleanup)
 
-	if failed > 0 {
-		fmt.Printf("❌ Execution failed: %d/%d completed, %d failed, %d skipped\n", completed, total, failed, skipped)
+	if gate := m.Declined(); gate != nil {
+		fmt.Printf("⏹ Run declined at %q: %d/%d completed, %d skipped\n", gate.Pause, completed, total, skipped)
+	} else if failed > 0 {
+		title := "❌ Execution failed"
+		if m.Stopped() {
+			// The failure is the command cancelled by stopping the run
+			title = "⏹ Run stopped"
+		}
+		fmt.Printf("%s: %d/%d completed, %d failed, %d skipped\n", title, completed, total, failed, skipped)
+		for _, cmd := range m.Commands() {
+			if cmd.Status == executor.StatusFailed && !cmd.Always {
+				fmt.Printf("   Failed step: %s (exit code %d)\n", summaryLabel(cmd), cmd.ExitCode)
+			}
+		}
+	} else if m.Stopped() {
+		fmt.Printf("⏹ Run stopped: %d/%d completed, %d skipped\n", completed, total, skipped)
 	} else {
-		fmt.Printf("✅ All commands completed successfully (%d/%d)\n", completed, total)
+		details := ""
+		if cached > 0 {
+			details += fmt.Sprintf(", %d cached", cached)
+		}
+		if skipped > 0 {
+			fmt.Printf("✅ All selected commands completed successfully (%d/%d%s, %d skipped)\n", completed, total, details, skipped)
+		} else {
+			fmt.Printf("✅ All commands completed successfully (%d/%d%s)\n", completed, total, details)
+		}
 	}
 
+	printCleanup(cleanup)
+
 	// Print log file path if available
 	if logPath := m.LoggerPath(); logPath != "" {
 		fmt.Printf("\n📝 Debug log available at: %s\n", logPath)
 	}
 }
 
-// readCommandsFromStdin reads commands from stdin, one per line
-func readCommandsFromStdin() []*executor.Command {
-	commands := make([]*executor.Command, 0)
-	scanner := bufio.NewScanner(os.Stdin)
+// printCleanup prints how the cleanup steps went
+func printCleanup(steps []*executor.Command) {
+	if len(steps) == 0 {
+		return
+	}
+
+	completed, failed := 0, 0
+	for _, cmd := range steps {
+		switch {
+		case cmd.Status.Succeeded():
+			completed++
+		case cmd.Status == executor.StatusFailed:
+			failed++
+		}
+	}
 
-	i := 0
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		// Skip empty lines
-		if line != "" {
-			commands = append(commands, executor.NewCommand(i, line))
-			i++
+	if failed == 0 {
+		fmt.Printf("🧹 Cleanup: %d/%d completed\n", completed, len(steps))
+		return
+	}
+	fmt.Printf("⚠️  Cleanup failed: %d/%d completed, %d failed\n", completed, len(steps), failed)
+	for _, cmd := range steps {
+		if cmd.Status == executor.StatusFailed {
+			fmt.Printf("   Failed cleanup step: %s (exit code %d)\n", summaryLabel(cmd), cmd.ExitCode)
 		}
 	}
+}
 
-	return commands
+// summaryLabel names a step in the summary, prefixed by its group
+func summaryLabel(cmd *executor.Command) string {
+	if cmd.Group != "" {
+		return cmd.Group + " › " + cmd.Label()
+	}
+	return cmd.Label()
+}
+
+// viewLog opens the log of an earlier run in the interface, read-only, and
+// returns the exit code
+func viewLog(args []string, cfg config.Config) int {
+	if len(args) != 1 {
+		fmt.Println("Usage: lazycommands view LOGFILE")
+		return 2
+	}
+	commands, err := log.Load(args[0])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	if !isTerminal(os.Stdout) {
+		fmt.Println("Error: view needs a terminal")
+		return 1
+	}
+
+	applyAppearance(cfg)
+	model := app.NewModel(commands, app.Options{
+		KeyMap:     cfg.KeyMap(),
+		Log:        log.Settings{Disabled: true},
+		Fullscreen: true,
+		Replay:     args[0],
+	})
+
+	// The output preview beside the list needs the whole screen
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	if _, err := p.Run(); err != nil {
+		fmt.Printf("\nError running program: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// readCommandsFromStdin reads commands from stdin, one per line.
+// Comments, line continuations and multi-line blocks are handled by the parser.
+func readCommandsFromStdin() ([]*executor.Command, error) {
+	entries, err := parser.Parse(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	commands := make([]*executor.Command, 0, len(entries))
+	for i, entry := range entries {
+		cmd := executor.NewCommand(i, entry.Raw)
+		cmd.Name = entry.Name
+		commands = append(commands, cmd)
+	}
+
+	return commands, nil
 }
 
 // printUsage prints the usage information
@@ -125,6 +674,46 @@ func printUsage() {
 	fmt.Println("Usage: lazycommands 'cmd1' 'cmd2' 'cmd3' ...")
 	fmt.Println("   or: echo 'cmd1' | lazycommands")
 	fmt.Println("   or: lazycommands << EOF")
+	fmt.Println("   or: lazycommands -f workflow.json")
+	fmt.Println("   or: lazycommands config")
+	fmt.Println("   or: lazycommands view LOGFILE")
+	fmt.Println()
+	fmt.Println("Flags:")
+	fmt.Println("  -f, --file PATH       Run the steps in a JSON workflow file")
+	fmt.Println("  --config PATH         Use this config file instead of the default")
+	fmt.Println("  --shell PATH          Shell used to run commands (default: $SHELL)")
+	fmt.Println("  --log-format FORMAT   Debug log format: text or json")
+	fmt.Println("  --log-dir PATH        Write debug logs to PATH (default: temp directory)")
+	fmt.Println("  --no-log              Write no debug log")
+	fmt.Println("  --theme NAME          Color theme: auto, dark, light, high-contrast, monochrome")
+	fmt.Println("  --icons SET           Status icons: emoji or ascii")
+	fmt.Println("  --fullscreen          Use the whole terminal; print a summary on exit")
+	fmt.Println("  --confirm             Review and deselect steps before anything runs")
+	fmt.Println("  --approve             Approve every approval gate without asking")
+	fmt.Println("  --input               Keep every step's input open, to answer prompts")
+	fmt.Println("                        by attaching (default: steps read end-of-file)")
+	fmt.Println("  --headless            Run without the terminal UI, as in CI")
+	fmt.Println("  --watch GLOB          Rerun the commands when matching files change")
+	fmt.Println("                        (repeatable; ** matches any directories)")
+	fmt.Println("  --no-cache            Run every step, ignoring cached results")
+	fmt.Println("  --cache-dir PATH      Store cached step results in PATH")
+	fmt.Println("  --on-success CMD      Run CMD when the run succeeds (repeatable)")
+	fmt.Println("  --on-failure CMD      Run CMD when the run fails (repeatable)")
+	fmt.Println("  --notify NOTIFIER     Notify when the run finishes: desktop, bell or")
+	fmt.Println("                        a webhook URL (repeatable)")
+	fmt.Println("  --secret-env NAME     Mask the value of $NAME in output and logs")
+	fmt.Println("                        (repeatable)")
+	fmt.Println("  --secret-pattern RE   Mask text matching RE in output and logs")
+	fmt.Println("                        (repeatable)")
+	fmt.Println("  --record FILE         Write an asciinema recording of the output to FILE")
+	fmt.Println("  --record-steps        With --record, write one recording per step,")
+	fmt.Println("                        numbered: FILE-01.cast, FILE-02.cast, ...")
+	fmt.Println("  -v, --version         Print version and exit")
+	fmt.Println()
+	fmt.Println("The config subcommand prints the effective configuration, merged from")
+	fmt.Printf("the defaults, %s and any flags.\n", config.DefaultPath())
+	fmt.Println("The view subcommand shows the steps, output and durations of an earlier")
+	fmt.Println("run from its debug log, text or JSON and optionally gzipped.")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  # Using arguments:")
@@ -141,6 +730,12 @@ func printUsage() {
 	fmt.Println("  # Using pipe:")
 	fmt.Println("  cat commands.txt | lazycommands")
 	fmt.Println()
+	fmt.Println("Command lists read from stdin may contain # comments, lines joined")
+	fmt.Println("with a trailing backslash, multi-line if/for/while/case blocks and")
+	fmt.Println("here-documents; each of these runs as a single command.")
+	fmt.Println("Prefix a line with 'name: ' to give the step a display name:")
+	fmt.Println("  build: go build ./...")
+	fmt.Println()
 }
 
 // printVersion prints version information